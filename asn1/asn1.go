@@ -0,0 +1,556 @@
+// Package asn1 provides helpers which generate ASN.1 values encoded in
+// DER or BER defined in X.690 from FuzzedDataProvider.
+package asn1
+
+import (
+	"bytes"
+	"math"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	fuzz "github.com/ngtcp2/fuzzeddataprovider-go"
+	"github.com/ngtcp2/fuzzeddataprovider-go/internal/gen"
+)
+
+// Tag classes defined in X.690.
+const (
+	ClassUniversal       = 0x00
+	ClassApplication     = 0x40
+	ClassContextSpecific = 0x80
+	ClassPrivate         = 0xc0
+	// Constructed is the bit which indicates the constructed encoding.
+	Constructed = 0x20
+)
+
+// Universal tag numbers defined in X.680.
+const (
+	TagBoolean         = 1
+	TagInteger         = 2
+	TagBitString       = 3
+	TagOctetString     = 4
+	TagNull            = 5
+	TagOID             = 6
+	TagUTF8String      = 12
+	TagSequence        = 16
+	TagSet             = 17
+	TagPrintableString = 19
+	TagIA5String       = 22
+	TagUTCTime         = 23
+	TagGeneralizedTime = 24
+	// TagContextSpecific stands for context-specific tags in
+	// Options.Tags.
+	TagContextSpecific = -1
+)
+
+var tags = []int{
+	TagBoolean,
+	TagInteger,
+	TagBitString,
+	TagOctetString,
+	TagNull,
+	TagOID,
+	TagUTF8String,
+	TagSequence,
+	TagSet,
+	TagPrintableString,
+	TagIA5String,
+	TagUTCTime,
+	TagGeneralizedTime,
+	TagContextSpecific,
+}
+
+const (
+	// maxIntegerLen is the maximum length of INTEGER content.
+	maxIntegerLen = 33
+	// maxArcs is the maximum number of arcs of OBJECT IDENTIFIER after
+	// the first two.
+	maxArcs = 8
+	// maxLowTagNumber is the maximum tag number which is encoded in
+	// the identifier octet.
+	maxLowTagNumber = 30
+	// maxHighTagNumber is the maximum tag number in the high tag
+	// number form generated.
+	maxHighTagNumber = 1<<21 - 1
+	// maxLengthOctets is the maximum number of subsequent octets in
+	// the long form of length generated.
+	maxLengthOctets = 4
+	// printableChars are the characters of PrintableString.
+	printableChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz" +
+		"0123456789 '()+,-./:=?"
+)
+
+var (
+	utcTimeMin         = time.Date(1950, 1, 1, 0, 0, 0, 0, time.UTC)
+	utcTimeMax         = time.Date(2049, 12, 31, 23, 59, 59, 0, time.UTC)
+	generalizedTimeMin = time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC)
+	generalizedTimeMax = time.Date(9999, 12, 31, 23, 59, 59, 999999999,
+		time.UTC)
+)
+
+// Options controls the values generated by this package.  The zero
+// value generates any universal type listed in the Tag constants and
+// context-specific tags in DER with default limits.
+type Options struct {
+	// Tags is the tag numbers of universal types, and
+	// TagContextSpecific, to generate.  SEQUENCE, SET and
+	// context-specific tags are generated only up to MaxDepth.
+	// Universal types which are not listed in the Tag constants are
+	// primitive with arbitrary contents.  NULL is generated beyond
+	// MaxDepth if Tags has no primitive type.  If Tags is empty, all
+	// of the Tag constants are generated.
+	Tags []int
+	// MaxDepth is the maximum nesting depth of constructed values.  If
+	// it is 0, 8 is used.
+	MaxDepth int
+	// MaxElements is the maximum number of elements of SEQUENCE, SET
+	// and constructed context-specific values.  If it is 0, 16 is
+	// used.
+	MaxElements int
+	// MaxDataLen is the maximum length of string contents.  If it is
+	// 0, 256 is used.
+	MaxDataLen int
+	// BER allows encodings which BER permits but DER does not, such as
+	// the long form of length when the short form suffices, length
+	// octets with leading zeros, indefinite length of constructed
+	// values, constructed strings, BOOLEAN true other than 0xff, unused
+	// bits of BIT STRING which are not zero and SET elements in any
+	// order.  Otherwise, the elements of SET are sorted by their
+	// encodings as DER requires for SET OF.
+	BER bool
+	// AllowIllegal allows values which violate X.690, such as lengths
+	// which do not match the contents, the reserved length octet 0xff,
+	// indefinite length of primitive values, INTEGER with redundant
+	// leading octets, OBJECT IDENTIFIER subidentifiers with leading
+	// 0x80 octets, invalid unused bits of BIT STRING, the high tag
+	// number form for small tag numbers and characters which are not
+	// allowed in the string type.
+	AllowIllegal bool
+}
+
+type valueGen struct {
+	fdp         *fuzz.FuzzedDataProvider
+	opts        *Options
+	tags        []int
+	maxDepth    int
+	maxElements int
+	maxDataLen  int
+	// illegal is true if the value being generated may violate X.690.
+	illegal bool
+}
+
+func newValueGen(fdp *fuzz.FuzzedDataProvider, opts *Options) *valueGen {
+	opts = gen.OrZero(opts)
+
+	g := &valueGen{
+		fdp:         fdp,
+		opts:        opts,
+		tags:        tags,
+		maxDepth:    gen.Limit(opts.MaxDepth, gen.DefaultMaxDepth),
+		maxElements: gen.Limit(opts.MaxElements, gen.DefaultMaxCount),
+		maxDataLen:  gen.Limit(opts.MaxDataLen, gen.DefaultMaxDataLen),
+	}
+
+	if len(opts.Tags) != 0 {
+		g.tags = opts.Tags
+	}
+
+	return g
+}
+
+// ConsumeValue returns an ASN.1 value encoded in TLV generated by
+// consuming bytes from the input data.  opts may be nil.  If there is
+// no input data left, it returns the value of the first type in
+// opts.Tags, which is BOOLEAN false by default.
+func ConsumeValue(fdp *fuzz.FuzzedDataProvider, opts *Options) []byte {
+	return newValueGen(fdp, opts).consumeValue(0)
+}
+
+// constructed returns true if the values of tag are constructed.
+func constructed(tag int) bool {
+	return tag == TagSequence || tag == TagSet || tag == TagContextSpecific
+}
+
+// consumeValue returns a value of the nesting depth depth.
+func (g *valueGen) consumeValue(depth int) []byte {
+	fdp := g.fdp
+
+	g.illegal = gen.ConsumeIllegal(fdp, g.opts.AllowIllegal)
+
+	ts := g.tags
+	if depth >= g.maxDepth {
+		ts = slices.DeleteFunc(slices.Clone(ts), constructed)
+	}
+
+	if len(ts) == 0 {
+		return g.appendPrimitive(nil, ClassUniversal, TagNull, nil)
+	}
+
+	switch tag := ts[fdp.ConsumeIntInRange(0, len(ts)-1)]; tag {
+	case TagBoolean:
+		return g.appendPrimitive(nil, ClassUniversal, tag, g.consumeBoolean())
+	case TagInteger:
+		return g.appendPrimitive(nil, ClassUniversal, tag, g.consumeInteger())
+	case TagBitString:
+		return g.appendPrimitive(nil, ClassUniversal, tag, g.consumeBitString())
+	case TagOctetString:
+		return g.appendString(nil, tag,
+			fdp.ConsumeBytes(fdp.ConsumeIntInRange(0, g.maxDataLen)))
+	case TagNull:
+		var content []byte
+		if g.illegal && fdp.ConsumeBool() {
+			content = fdp.ConsumeBytes(fdp.ConsumeIntInRange(1, g.maxDataLen))
+		}
+
+		return g.appendPrimitive(nil, ClassUniversal, tag, content)
+	case TagOID:
+		return g.appendPrimitive(nil, ClassUniversal, tag, g.consumeOID())
+	case TagUTF8String, TagPrintableString, TagIA5String:
+		return g.appendString(nil, tag, g.consumeString(tag))
+	case TagUTCTime:
+		t := fdp.ConsumeTime(utcTimeMin, utcTimeMax)
+
+		return g.appendString(nil, tag, t.AppendFormat(nil, "060102150405Z"))
+	case TagGeneralizedTime:
+		return g.appendString(nil, tag, g.consumeGeneralizedTime())
+	case TagSequence, TagSet:
+		return g.appendConstructed(nil, ClassUniversal, tag, depth)
+	case TagContextSpecific:
+		return g.appendContextSpecific(nil, depth)
+	default:
+		return g.appendPrimitive(nil, ClassUniversal, tag,
+			fdp.ConsumeBytes(fdp.ConsumeIntInRange(0, g.maxDataLen)))
+	}
+}
+
+// appendIdentifier appends the identifier octets of class and tag
+// number tag to b.  If g.illegal is true, the high tag number form
+// might be used for a small tag number, or have a leading 0x80 octet.
+func (g *valueGen) appendIdentifier(b []byte, class byte, tag int) []byte {
+	fdp := g.fdp
+
+	highForm := tag > maxLowTagNumber ||
+		g.illegal && fdp.ConsumeBool()
+	if !highForm {
+		return append(b, class|byte(tag))
+	}
+
+	b = append(b, class|0x1f)
+
+	if g.illegal && fdp.ConsumeBool() {
+		b = append(b, 0x80)
+	}
+
+	return appendBase128(b, uint64(tag))
+}
+
+// appendBase128 appends v in base 128 with the minimum number of
+// octets to b.  The most significant bit of all octets except for the
+// last one is set.
+func appendBase128(b []byte, v uint64) []byte {
+	n := 1
+	for w := v >> 7; w != 0; w >>= 7 {
+		n++
+	}
+
+	for i := n - 1; i > 0; i-- {
+		b = append(b, byte(v>>(7*i))|0x80)
+	}
+
+	return append(b, byte(v)&0x7f)
+}
+
+// appendLength appends the length octets of content length n to b.
+// If g.illegal is true, the length might not match the content or be
+// the reserved value.
+func (g *valueGen) appendLength(b []byte, n int) []byte {
+	fdp := g.fdp
+
+	if g.illegal && fdp.ConsumeBool() {
+		return append(b, 0xff)
+	}
+
+	n = int(gen.ConsumeLength(fdp, g.illegal, uint64(n), math.MaxInt))
+
+	var l []byte
+
+	for v := n; v != 0; v >>= 8 {
+		l = append(l, byte(v))
+	}
+
+	if g.opts.BER && fdp.ConsumeBool() {
+		// Long form which might have leading zeros.
+		for len(l) < maxLengthOctets && fdp.ConsumeBool() {
+			l = append(l, 0)
+		}
+
+		if len(l) == 0 {
+			l = append(l, 0)
+		}
+	} else if n < 0x80 {
+		return append(b, byte(n))
+	}
+
+	slices.Reverse(l)
+
+	b = append(b, 0x80|byte(len(l)))
+
+	return append(b, l...)
+}
+
+// appendPrimitive appends a primitive value of class and tag number
+// tag whose contents octets are content to b.  If g.illegal is true,
+// it might use indefinite length.
+func (g *valueGen) appendPrimitive(
+	b []byte, class byte, tag int, content []byte,
+) []byte {
+	b = g.appendIdentifier(b, class, tag)
+
+	if g.illegal && g.fdp.ConsumeBool() {
+		b = append(b, 0x80)
+		b = append(b, content...)
+
+		return append(b, 0, 0)
+	}
+
+	b = g.appendLength(b, len(content))
+
+	return append(b, content...)
+}
+
+// appendConstructedContent appends a constructed value of class and
+// tag number tag whose contents octets are content to b.  In BER, it
+// might use indefinite length.
+func (g *valueGen) appendConstructedContent(
+	b []byte, class byte, tag int, content []byte,
+) []byte {
+	b = g.appendIdentifier(b, class|Constructed, tag)
+
+	if g.opts.BER && g.fdp.ConsumeBool() {
+		b = append(b, 0x80)
+		b = append(b, content...)
+
+		// End-of-contents octets.
+		return append(b, 0, 0)
+	}
+
+	b = g.appendLength(b, len(content))
+
+	return append(b, content...)
+}
+
+// appendConstructed appends a constructed value of class and tag
+// number tag whose contents are the encodings of elements to b.  In
+// DER, the elements of SET are sorted by their encodings.
+func (g *valueGen) appendConstructed(
+	b []byte, class byte, tag int, depth int,
+) []byte {
+	var elements [][]byte
+
+	illegal := g.illegal
+
+	for range g.maxElements {
+		if g.fdp.ConsumeUint8() == 0 {
+			break
+		}
+
+		elements = append(elements, g.consumeValue(depth+1))
+	}
+
+	g.illegal = illegal
+
+	if class == ClassUniversal && tag == TagSet && !g.opts.BER {
+		slices.SortFunc(elements, bytes.Compare)
+	}
+
+	return g.appendConstructedContent(b, class, tag, bytes.Join(elements, nil))
+}
+
+// appendContextSpecific appends a context-specific value to b.  It is
+// either constructed, which contains elements like explicit tagging,
+// or primitive, which contains arbitrary octets like implicit tagging.
+func (g *valueGen) appendContextSpecific(b []byte, depth int) []byte {
+	fdp := g.fdp
+
+	tag := fdp.ConsumeIntInRange(0, maxLowTagNumber)
+	if fdp.ConsumeBool() {
+		tag = fdp.ConsumeIntInRange(maxLowTagNumber+1, maxHighTagNumber)
+	}
+
+	if fdp.ConsumeBool() {
+		return g.appendConstructed(b, ClassContextSpecific, tag, depth)
+	}
+
+	return g.appendPrimitive(b, ClassContextSpecific, tag,
+		fdp.ConsumeBytes(fdp.ConsumeIntInRange(0, g.maxDataLen)))
+}
+
+// appendString appends OCTET STRING, a restricted character string or
+// a time of tag number tag whose content is s to b.  In BER, the value
+// might be constructed from OCTET STRING segments.
+func (g *valueGen) appendString(b []byte, tag int, s []byte) []byte {
+	fdp := g.fdp
+
+	if !g.opts.BER || !fdp.ConsumeBool() {
+		return g.appendPrimitive(b, ClassUniversal, tag, s)
+	}
+
+	var segments []byte
+
+	for len(s) != 0 {
+		n := fdp.ConsumeIntInRange(1, len(s))
+		segments = g.appendPrimitive(segments, ClassUniversal, TagOctetString,
+			s[:n])
+		s = s[n:]
+	}
+
+	return g.appendConstructedContent(b, ClassUniversal, tag, segments)
+}
+
+// consumeBoolean returns the contents octets of BOOLEAN.
+func (g *valueGen) consumeBoolean() []byte {
+	fdp := g.fdp
+
+	switch {
+	case g.illegal && fdp.ConsumeBool():
+		return fdp.ConsumeBytes(fdp.ConsumeIntInRange(0, 2))
+	case !fdp.ConsumeBool():
+		return []byte{0}
+	case g.opts.BER:
+		// Any non-zero value is true in BER.
+		return []byte{fdp.ConsumeUint8InRange(1, 0xff)}
+	default:
+		return []byte{0xff}
+	}
+}
+
+// consumeInteger returns the contents octets of INTEGER, which are the
+// two's complement of the value in the minimum number of octets.
+func (g *valueGen) consumeInteger() []byte {
+	fdp := g.fdp
+
+	b := fdp.ConsumeBytes(fdp.ConsumeIntInRange(1, maxIntegerLen))
+	if len(b) == 0 {
+		b = []byte{0}
+	}
+
+	if g.illegal && fdp.ConsumeBool() {
+		if fdp.ConsumeBool() {
+			return nil
+		}
+
+		// Redundant leading octet.
+		if b[0]&0x80 == 0 {
+			return append([]byte{0}, b...)
+		}
+
+		return append([]byte{0xff}, b...)
+	}
+
+	for len(b) > 1 && (b[0] == 0 && b[1]&0x80 == 0 ||
+		b[0] == 0xff && b[1]&0x80 != 0) {
+		b = b[1:]
+	}
+
+	return b
+}
+
+// consumeBitString returns the contents octets of BIT STRING.
+func (g *valueGen) consumeBitString() []byte {
+	fdp := g.fdp
+
+	data := fdp.ConsumeBytes(fdp.ConsumeIntInRange(0, g.maxDataLen))
+
+	unused := byte(0)
+	if len(data) != 0 {
+		unused = fdp.ConsumeUint8InRange(0, 7)
+	}
+
+	switch {
+	case g.illegal && fdp.ConsumeBool():
+		unused = fdp.ConsumeUint8()
+	case len(data) != 0 && !g.opts.BER:
+		// The unused bits are zero in DER.
+		data[len(data)-1] &^= 1<<unused - 1
+	}
+
+	return append([]byte{unused}, data...)
+}
+
+// consumeOID returns the contents octets of OBJECT IDENTIFIER.
+func (g *valueGen) consumeOID() []byte {
+	fdp := g.fdp
+
+	first := fdp.ConsumeUint64InRange(0, 2)
+
+	var second uint64
+	if first < 2 {
+		second = fdp.ConsumeUint64InRange(0, 39)
+	} else {
+		second = fdp.ConsumeUint64InRange(0, 1<<32)
+	}
+
+	b := appendBase128(nil, first*40+second)
+
+	for range fdp.ConsumeIntInRange(0, maxArcs) {
+		if g.illegal && fdp.ConsumeBool() {
+			// Subidentifier which is not in the minimum number of
+			// octets.
+			b = append(b, 0x80)
+		}
+
+		b = appendBase128(b, fdp.ConsumeUint64InRange(0, 1<<32))
+	}
+
+	return b
+}
+
+// consumeString returns the content of a restricted character string
+// of tag number tag.  Characters which are not allowed in the type are
+// replaced unless g.illegal is true.
+func (g *valueGen) consumeString(tag int) []byte {
+	s := g.fdp.ConsumeDictionaryString(g.maxDataLen)
+	if g.illegal {
+		return []byte(s)
+	}
+
+	switch tag {
+	case TagPrintableString:
+		return []byte(strings.Map(func(r rune) rune {
+			if r < utf8.RuneSelf && strings.ContainsRune(printableChars, r) {
+				return r
+			}
+
+			return rune(printableChars[int(r)%len(printableChars)])
+		}, s))
+	case TagIA5String:
+		b := []byte(s)
+		for i := range b {
+			b[i] &= 0x7f
+		}
+
+		return b
+	default:
+		return []byte(strings.ToValidUTF8(s, string(utf8.RuneError)))
+	}
+}
+
+// consumeGeneralizedTime returns the content of GeneralizedTime in UTC
+// with optional fractional seconds.  DER forbids trailing zeros in
+// fractional seconds.
+func (g *valueGen) consumeGeneralizedTime() []byte {
+	fdp := g.fdp
+
+	t := fdp.ConsumeTime(generalizedTimeMin, generalizedTimeMax)
+	if fdp.ConsumeBool() {
+		t = t.Truncate(time.Second)
+	}
+
+	layout := "20060102150405.999999999Z"
+	if g.opts.BER && fdp.ConsumeBool() {
+		layout = "20060102150405.000000000Z"
+	}
+
+	return t.AppendFormat(nil, layout)
+}
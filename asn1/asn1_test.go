@@ -0,0 +1,274 @@
+package asn1
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+
+	fuzz "github.com/ngtcp2/fuzzeddataprovider-go"
+	"github.com/ngtcp2/fuzzeddataprovider-go/internal/gentest"
+)
+
+type reader struct {
+	gentest.Reader
+	der bool
+}
+
+// sub returns a reader of b which inherits the rules of r.
+func (r *reader) sub(b []byte) *reader {
+	return &reader{gentest.Reader{B: b}, r.der}
+}
+
+// base128 reads an integer in base 128 with the minimum number of
+// octets.
+func (r *reader) base128() uint64 {
+	var v uint64
+
+	for i := 0; ; i++ {
+		c := r.Byte()
+		r.Check(i != 0 || c != 0x80)
+		r.Check(i < 10)
+
+		v = v<<7 | uint64(c&0x7f)
+
+		if c&0x80 == 0 {
+			return v
+		}
+	}
+}
+
+// value validates the next value according to X.690, and returns its
+// encoding.
+func (r *reader) value() []byte {
+	b := r.B
+	id := r.Byte()
+	class, cons := id&0xc0, id&Constructed != 0
+	tag := int(id & 0x1f)
+
+	if tag == 0x1f {
+		tag = int(r.base128())
+		r.Check(tag > maxLowTagNumber)
+	}
+
+	var content *reader
+
+	if c := r.Byte(); c == 0x80 {
+		// Indefinite length.
+		r.Check(!r.der && cons)
+
+		start := r.B
+
+		for !r.endOfContents() {
+			r.value()
+		}
+
+		content = r.sub(start[:len(start)-len(r.B)-2])
+	} else {
+		n := int(c)
+
+		if c > 0x80 {
+			r.Check(c != 0xff)
+
+			l := r.Bytes(uint64(c & 0x7f))
+			r.Check(len(l) <= 8)
+
+			n = 0
+			for _, c := range l {
+				n = n<<8 | int(c)
+			}
+
+			r.Check(!r.der || l[0] != 0 && n >= 0x80)
+		}
+
+		content = r.sub(r.Bytes(uint64(n)))
+	}
+
+	switch {
+	case class == ClassContextSpecific && cons:
+		content.values()
+	case class != ClassUniversal:
+	default:
+		content.universal(tag, cons)
+	}
+
+	return b[:len(b)-len(r.B)]
+}
+
+// endOfContents consumes the end-of-contents octets if they are next.
+func (r *reader) endOfContents() bool {
+	r.Check(len(r.B) >= 2)
+
+	if r.B[0] != 0 || r.B[1] != 0 {
+		return false
+	}
+
+	r.Bytes(2)
+
+	return true
+}
+
+func (r *reader) values() [][]byte {
+	var values [][]byte
+
+	for len(r.B) != 0 {
+		values = append(values, r.value())
+	}
+
+	return values
+}
+
+// segments returns the concatenation of OCTET STRING segments of a
+// constructed string.
+func (r *reader) segments() []byte {
+	r.Check(!r.der)
+
+	var s []byte
+
+	for len(r.B) != 0 {
+		seg := r.sub(r.value())
+		id := seg.Byte()
+		r.Check(id&0x1f == TagOctetString)
+
+		// Skip the length octets.
+		if c := seg.Byte(); c > 0x80 {
+			seg.Bytes(uint64(c & 0x7f))
+		}
+
+		s = append(s, seg.B...)
+	}
+
+	return s
+}
+
+func (r *reader) universal(tag int, cons bool) {
+	switch tag {
+	case TagSequence, TagSet:
+		r.Check(cons)
+
+		values := r.values()
+		if tag == TagSet && r.der {
+			r.Check(isSorted(values))
+		}
+
+		return
+	case TagOctetString, TagUTF8String, TagPrintableString, TagIA5String,
+		TagUTCTime, TagGeneralizedTime:
+		s := r.B
+		if cons {
+			s = r.segments()
+		}
+
+		r.string(tag, s)
+
+		return
+	}
+
+	r.Check(!cons)
+
+	switch tag {
+	case TagBoolean:
+		r.Check(len(r.B) == 1 && (!r.der || r.B[0] == 0 || r.B[0] == 0xff))
+	case TagInteger:
+		r.Check(len(r.B) != 0)
+		r.Check(len(r.B) == 1 ||
+			!(r.B[0] == 0 && r.B[1]&0x80 == 0) &&
+				!(r.B[0] == 0xff && r.B[1]&0x80 != 0))
+	case TagBitString:
+		unused := r.Byte()
+		r.Check(unused <= 7 && (len(r.B) != 0 || unused == 0))
+		r.Check(!r.der || len(r.B) == 0 ||
+			r.B[len(r.B)-1]&(1<<unused-1) == 0)
+	case TagNull:
+		r.Check(len(r.B) == 0)
+	case TagOID:
+		r.Check(len(r.B) != 0)
+
+		for len(r.B) != 0 {
+			r.base128()
+		}
+	}
+
+	r.B = nil
+}
+
+func (r *reader) string(tag int, s []byte) {
+	switch tag {
+	case TagUTF8String:
+		r.Check(utf8.Valid(s))
+	case TagPrintableString:
+		for _, c := range s {
+			r.Check(strings.IndexByte(printableChars, c) != -1)
+		}
+	case TagIA5String:
+		for _, c := range s {
+			r.Check(c < 0x80)
+		}
+	case TagUTCTime:
+		_, err := time.Parse("060102150405Z", string(s))
+		r.Check(err == nil)
+	case TagGeneralizedTime:
+		_, err := time.Parse("20060102150405Z", string(s))
+		if err != nil {
+			_, err = time.Parse("20060102150405.999999999Z", string(s))
+			r.Check(err == nil && (!r.der || !bytes.HasSuffix(s, []byte("0Z"))))
+		}
+	}
+}
+
+func isSorted(values [][]byte) bool {
+	for i := 1; i < len(values); i++ {
+		if bytes.Compare(values[i-1], values[i]) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parse validates that b is a single value.  If der is true, it also
+// requires DER.
+func parse(b []byte, der bool) error {
+	return gentest.Parse(func() {
+		r := &reader{gentest.Reader{B: b}, der}
+		r.value()
+		r.Check(len(r.B) == 0)
+	})
+}
+
+func TestConsumeValue(t *testing.T) {
+	fdp := fuzz.NewFuzzedDataProvider(nil)
+
+	assert.Equal(t, []byte{TagBoolean, 0x01, 0x00}, ConsumeValue(fdp, nil))
+
+	fdp = fuzz.NewFuzzedDataProvider([]byte{0x01, 0x00})
+
+	assert.Equal(t, []byte{TagBoolean, 0x01, 0xff}, ConsumeValue(fdp, nil))
+
+	// SET of INTEGER 2 and 1, whose elements are sorted.
+	fdp = fuzz.NewFuzzedDataProvider([]byte{
+		0x02, 0x01, 0x00, 0x00, 0x01, 0x00, 0x00, 0x01, 0x01,
+	})
+
+	assert.Equal(t, []byte{
+		Constructed | TagSet, 0x06,
+		TagInteger, 0x01, 0x01,
+		TagInteger, 0x01, 0x02,
+	}, ConsumeValue(fdp, &Options{
+		Tags: []int{TagInteger, TagSet},
+	}))
+}
+
+func TestConsumeValueProperty(t *testing.T) {
+	for _, ber := range []bool{false, true} {
+		gentest.Check(t, func(data []byte, illegal bool) []byte {
+			return ConsumeValue(fuzz.NewFuzzedDataProvider(data), &Options{
+				BER:          ber,
+				AllowIllegal: illegal,
+			})
+		}, func(b []byte) error { return parse(b, !ber) })
+	}
+}
@@ -0,0 +1,61 @@
+package fuzz
+
+// BitReader consumes bits from the front of the input data, most
+// significant bit first, so that several small fields packed in a
+// header share a byte.  A byte is taken from the input data only when
+// all bits of the previous one have been consumed.
+type BitReader struct {
+	fdp *FuzzedDataProvider
+	// cur is the byte being consumed, and n is the number of bits in
+	// cur which are not consumed yet.
+	cur byte
+	n   uint
+}
+
+// NewBitReader returns new BitReader which consumes bits from fdp.
+// The bits left in the current byte are not visible to the other
+// methods of fdp, which continue from the next byte.
+func (fdp *FuzzedDataProvider) NewBitReader() *BitReader {
+	return &BitReader{
+		fdp: fdp,
+	}
+}
+
+// ConsumeBits returns the next n bits as the least significant bits
+// of the result.  n must be in the range [0, 64].  If there is no
+// input data left, the missing bits are zeros.
+func (r *BitReader) ConsumeBits(n int) uint64 {
+	if n < 0 || n > 64 {
+		panic("n must be in the range [0, 64]")
+	}
+
+	var v uint64
+
+	for k := uint(n); k > 0; {
+		if r.n == 0 {
+			var b [1]byte
+
+			r.fdp.consumeFixed(b[:])
+			r.cur = b[0]
+			r.n = 8
+		}
+
+		m := min(k, r.n)
+		v = v<<m | uint64(r.cur>>(r.n-m))&(1<<m-1)
+		r.n -= m
+		k -= m
+	}
+
+	return v
+}
+
+// ConsumeBitBool returns true if the next bit is 1.
+func (r *BitReader) ConsumeBitBool() bool {
+	return r.ConsumeBits(1) == 1
+}
+
+// Align discards the bits left in the current byte, so that the next
+// ConsumeBits starts from a byte boundary.
+func (r *BitReader) Align() {
+	r.n = 0
+}
@@ -0,0 +1,42 @@
+package fuzz
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBitReader(t *testing.T) {
+	// QUIC long header with Initial packet type and 4-byte packet
+	// number, followed by Version.
+	fdp := NewFuzzedDataProvider([]byte{0xc3, 0x00, 0x00, 0x00, 0x01, 0xab})
+	r := fdp.NewBitReader()
+
+	assert.True(t, r.ConsumeBitBool())
+	assert.True(t, r.ConsumeBitBool())
+	assert.Equal(t, uint64(0), r.ConsumeBits(2))
+	assert.Equal(t, uint64(0), r.ConsumeBits(2))
+	assert.Equal(t, uint64(3), r.ConsumeBits(2))
+	assert.Equal(t, uint64(1), r.ConsumeBits(32))
+	assert.Equal(t, uint64(0xa), r.ConsumeBits(4))
+
+	r.Align()
+
+	assert.Equal(t, uint64(0), r.ConsumeBits(0))
+	assert.False(t, r.ConsumeBitBool())
+	assert.Equal(t, 0, fdp.RemainingBytes())
+}
+
+func TestBitReaderSpanBytes(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{
+		0x0f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xf0,
+	})
+	r := fdp.NewBitReader()
+
+	assert.Equal(t, uint64(0), r.ConsumeBits(4))
+	assert.Equal(t, uint64(0xffffffffffffffff), r.ConsumeBits(64))
+	assert.Equal(t, uint64(0), r.ConsumeBits(7))
+
+	assert.Panics(t, func() { r.ConsumeBits(65) })
+	assert.Panics(t, func() { r.ConsumeBits(-1) })
+}
@@ -0,0 +1,520 @@
+// Package cbor provides helpers which generate CBOR data items defined
+// in RFC 8949 from FuzzedDataProvider.
+package cbor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	fuzz "github.com/ngtcp2/fuzzeddataprovider-go"
+	"github.com/ngtcp2/fuzzeddataprovider-go/internal/gen"
+)
+
+// Major types defined in RFC 8949.
+const (
+	MajorUint = iota
+	MajorNegInt
+	MajorBytes
+	MajorText
+	MajorArray
+	MajorMap
+	MajorTag
+	MajorSimple
+)
+
+// Additional information values defined in RFC 8949.
+const (
+	aiUint8      = 24
+	aiUint16     = 25
+	aiUint32     = 26
+	aiUint64     = 27
+	aiIndefinite = 31
+)
+
+// Simple values and float encodings of major type 7 defined in RFC
+// 8949.
+const (
+	SimpleFalse     = 20
+	SimpleTrue      = 21
+	SimpleNull      = 22
+	SimpleUndefined = 23
+	simpleUint8     = 24
+	simpleFloat16   = 25
+	simpleFloat32   = 26
+	simpleFloat64   = 27
+	// Break is the "break" stop code which terminates indefinite
+	// length items.
+	Break = 0xff
+)
+
+// Tags registered in RFC 8949.
+const (
+	TagDateTime      = 0
+	TagEpochDateTime = 1
+	TagPosBignum     = 2
+	TagNegBignum     = 3
+	TagDecimal       = 4
+	TagBigfloat      = 5
+	TagURI           = 32
+	TagSelfDescribed = 55799
+)
+
+var tags = []uint64{
+	TagDateTime,
+	TagEpochDateTime,
+	TagPosBignum,
+	TagNegBignum,
+	TagDecimal,
+	TagBigfloat,
+	TagURI,
+	TagSelfDescribed,
+}
+
+// leafMajors are the major types which do not contain other data
+// items.
+var leafMajors = []uint8{
+	MajorUint,
+	MajorNegInt,
+	MajorBytes,
+	MajorText,
+	MajorSimple,
+}
+
+var majors = []uint8{
+	MajorUint,
+	MajorNegInt,
+	MajorBytes,
+	MajorText,
+	MajorArray,
+	MajorMap,
+	MajorTag,
+	MajorSimple,
+}
+
+// argumentMax are the maximum arguments which are encoded in the
+// initial byte, and in 1, 2, 4 and 8 following bytes respectively.
+var argumentMax = []uint64{
+	aiUint8 - 1,
+	math.MaxUint8,
+	math.MaxUint16,
+	math.MaxUint32,
+	math.MaxUint64,
+}
+
+// Options controls the data items generated by this package.  The zero
+// value generates well-formed data items in preferred serialization
+// with default limits.
+type Options struct {
+	// MaxDepth is the maximum nesting depth of arrays, maps and tags.
+	// If it is 0, 8 is used.
+	MaxDepth int
+	// MaxItems is the maximum number of elements of an array, and
+	// pairs of a map.  If it is 0, 16 is used.
+	MaxItems int
+	// MaxDataLen is the maximum length of byte and text strings.  If
+	// it is 0, 256 is used.
+	MaxDataLen int
+	// NonCanonical allows encodings which are well-formed but not in
+	// preferred serialization, that is, integer arguments longer than
+	// necessary, indefinite length strings, arrays and maps, and floats
+	// wider than necessary.  Otherwise, floats are encoded in the
+	// shortest width which represents them exactly.
+	NonCanonical bool
+	// AllowIllegal allows malformed data items, such as reserved
+	// additional information values, stray break codes, truncated
+	// arguments, lengths which do not match the content, indefinite
+	// length strings with chunks of another type and invalid UTF-8 in
+	// text strings.
+	AllowIllegal bool
+}
+
+type itemGen struct {
+	fdp        *fuzz.FuzzedDataProvider
+	opts       *Options
+	maxDepth   int
+	maxItems   int
+	maxDataLen int
+	// illegal is true if the data item being generated may be
+	// malformed.
+	illegal bool
+}
+
+func newItemGen(fdp *fuzz.FuzzedDataProvider, opts *Options) *itemGen {
+	opts = gen.OrZero(opts)
+
+	return &itemGen{
+		fdp:        fdp,
+		opts:       opts,
+		maxDepth:   gen.Limit(opts.MaxDepth, gen.DefaultMaxDepth),
+		maxItems:   gen.Limit(opts.MaxItems, gen.DefaultMaxCount),
+		maxDataLen: gen.Limit(opts.MaxDataLen, gen.DefaultMaxDataLen),
+	}
+}
+
+// ConsumeItem returns an encoded CBOR data item generated by consuming
+// bytes from the input data.  Map keys are distinct in their encoded
+// form unless opts.AllowIllegal is true.  Tag contents are not checked
+// against the semantics of the tag except that bignums contain byte
+// strings.  opts may be nil.  If there is no input data left, it
+// returns unsigned integer 0.
+func ConsumeItem(fdp *fuzz.FuzzedDataProvider, opts *Options) []byte {
+	return newItemGen(fdp, opts).appendItem(nil, 0)
+}
+
+func (g *itemGen) appendItem(b []byte, depth int) []byte {
+	fdp := g.fdp
+
+	g.illegal = gen.ConsumeIllegal(fdp, g.opts.AllowIllegal)
+	if g.illegal && fdp.ConsumeBool() {
+		return g.appendMalformed(b)
+	}
+
+	ms := majors
+	if depth >= g.maxDepth {
+		ms = leafMajors
+	}
+
+	switch major := ms[fdp.ConsumeIntInRange(0, len(ms)-1)]; major {
+	case MajorUint, MajorNegInt:
+		class := fdp.ConsumeIntInRange(0, len(argumentMax)-1)
+
+		return g.appendHead(b, major, fdp.ConsumeUint64InRange(0,
+			argumentMax[class]))
+	case MajorBytes:
+		return g.appendString(b, major,
+			fdp.ConsumeBytes(fdp.ConsumeIntInRange(0, g.maxDataLen)))
+	case MajorText:
+		s := fdp.ConsumeDictionaryString(g.maxDataLen)
+		if !g.illegal {
+			s = strings.ToValidUTF8(s, string(utf8.RuneError))
+		}
+
+		return g.appendString(b, major, []byte(s))
+	case MajorArray:
+		return g.appendArray(b, depth)
+	case MajorMap:
+		return g.appendMap(b, depth)
+	case MajorTag:
+		return g.appendTag(b, depth)
+	default:
+		return g.appendSimple(b)
+	}
+}
+
+// appendHead appends the initial byte and the argument v of major type
+// major to b.  If g.opts.NonCanonical is true, the argument might be
+// longer than necessary.
+func (g *itemGen) appendHead(b []byte, major uint8, v uint64) []byte {
+	n, _ := slices.BinarySearch(argumentMax, v)
+
+	if g.opts.NonCanonical && g.fdp.ConsumeBool() {
+		n = g.fdp.ConsumeIntInRange(n, len(argumentMax)-1)
+	}
+
+	return appendArgument(b, major, n, v)
+}
+
+// appendArgument appends the initial byte and the argument v in n-th
+// class of argumentMax to b.
+func appendArgument(b []byte, major uint8, n int, v uint64) []byte {
+	switch n {
+	case 0:
+		return append(b, major<<5|byte(v))
+	case 1:
+		return append(b, major<<5|aiUint8, byte(v))
+	case 2:
+		return binary.BigEndian.AppendUint16(append(b, major<<5|aiUint16),
+			uint16(v))
+	case 3:
+		return binary.BigEndian.AppendUint32(append(b, major<<5|aiUint32),
+			uint32(v))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, major<<5|aiUint64), v)
+	}
+}
+
+// appendLength appends the head of major type major with length n to
+// b.  If g.illegal is true, the length might not match the content.
+func (g *itemGen) appendLength(b []byte, major uint8, n int) []byte {
+	return g.appendHead(b, major,
+		gen.ConsumeLength(g.fdp, g.illegal, uint64(n), math.MaxUint64))
+}
+
+// indefinite returns true if the string, array or map being generated
+// uses indefinite length encoding.
+func (g *itemGen) indefinite() bool {
+	return g.opts.NonCanonical && g.fdp.ConsumeBool()
+}
+
+// appendString appends byte or text string data of major type major to
+// b.  An indefinite length string is split into chunks at UTF-8
+// character boundaries.
+func (g *itemGen) appendString(b []byte, major uint8, data []byte) []byte {
+	fdp := g.fdp
+
+	if !g.indefinite() {
+		b = g.appendLength(b, major, len(data))
+
+		return append(b, data...)
+	}
+
+	b = append(b, major<<5|aiIndefinite)
+
+	for len(data) != 0 {
+		n := fdp.ConsumeIntInRange(1, len(data))
+		for major == MajorText && n < len(data) && !utf8.RuneStart(data[n]) {
+			n++
+		}
+
+		// A chunk must have the same major type as the string.
+		chunkMajor := major
+		if g.illegal && fdp.ConsumeBool() {
+			chunkMajor ^= MajorBytes ^ MajorText
+		}
+
+		b = g.appendLength(b, chunkMajor, n)
+		b = append(b, data[:n]...)
+		data = data[n:]
+	}
+
+	return append(b, Break)
+}
+
+// appendArray appends an array to b.
+func (g *itemGen) appendArray(b []byte, depth int) []byte {
+	var items [][]byte
+
+	illegal := g.illegal
+
+	for range g.maxItems {
+		if g.fdp.ConsumeUint8() == 0 {
+			break
+		}
+
+		items = append(items, g.appendItem(nil, depth+1))
+	}
+
+	g.illegal = illegal
+
+	return g.appendContainer(b, MajorArray, items)
+}
+
+// appendMap appends a map to b.
+func (g *itemGen) appendMap(b []byte, depth int) []byte {
+	var (
+		keys  [][]byte
+		pairs [][]byte
+	)
+
+	illegal := g.illegal
+
+	for range g.maxItems {
+		if g.fdp.ConsumeUint8() == 0 {
+			break
+		}
+
+		key := g.appendItem(nil, depth+1)
+		value := g.appendItem(nil, depth+1)
+
+		if !illegal && slices.ContainsFunc(keys, func(k []byte) bool {
+			return bytes.Equal(k, key)
+		}) {
+			continue
+		}
+
+		keys = append(keys, key)
+		pairs = append(pairs, append(key, value...))
+	}
+
+	g.illegal = illegal
+
+	return g.appendContainer(b, MajorMap, pairs)
+}
+
+// appendContainer appends an array or a map of major type major whose
+// elements or pairs are items to b.
+func (g *itemGen) appendContainer(b []byte, major uint8, items [][]byte) []byte {
+	indefinite := g.indefinite()
+	if indefinite {
+		b = append(b, major<<5|aiIndefinite)
+	} else {
+		b = g.appendLength(b, major, len(items))
+	}
+
+	for _, item := range items {
+		b = append(b, item...)
+	}
+
+	if indefinite {
+		b = append(b, Break)
+	}
+
+	return b
+}
+
+// appendTag appends a tagged data item to b.
+func (g *itemGen) appendTag(b []byte, depth int) []byte {
+	fdp := g.fdp
+
+	var tag uint64
+
+	if fdp.ConsumeBool() {
+		tag = fdp.ConsumeUint64()
+	} else {
+		tag = tags[fdp.ConsumeIntInRange(0, len(tags)-1)]
+	}
+
+	b = g.appendHead(b, MajorTag, tag)
+
+	if tag == TagPosBignum || tag == TagNegBignum {
+		return g.appendString(b, MajorBytes,
+			fdp.ConsumeBytes(fdp.ConsumeIntInRange(0, g.maxDataLen)))
+	}
+
+	illegal := g.illegal
+	b = g.appendItem(b, depth+1)
+	g.illegal = illegal
+
+	return b
+}
+
+// appendSimple appends a simple value or a float to b.
+func (g *itemGen) appendSimple(b []byte) []byte {
+	fdp := g.fdp
+
+	switch v := fdp.ConsumeIntInRange(0, simpleFloat64); v {
+	case simpleUint8:
+		// Simple values less than 32 must be encoded in the initial
+		// byte.
+		return append(b, MajorSimple<<5|simpleUint8,
+			fdp.ConsumeUint8InRange(32, math.MaxUint8))
+	case simpleFloat16:
+		return binary.BigEndian.AppendUint16(
+			append(b, MajorSimple<<5|simpleFloat16), fdp.ConsumeUint16())
+	case simpleFloat32:
+		return g.appendFloat32(b, math.Float32bits(fdp.ConsumeFloat32()))
+	case simpleFloat64:
+		return g.appendFloat64(b, math.Float64bits(fdp.ConsumeFloat64()))
+	default:
+		return append(b, MajorSimple<<5|byte(v))
+	}
+}
+
+// appendFloat32 appends single-precision float of bits v to b.  Unless
+// g.opts.NonCanonical is true, it is encoded in half-precision if that
+// represents it exactly.
+func (g *itemGen) appendFloat32(b []byte, v uint32) []byte {
+	if h, ok := float32To16(v); ok && !g.opts.NonCanonical {
+		return binary.BigEndian.AppendUint16(
+			append(b, MajorSimple<<5|simpleFloat16), h)
+	}
+
+	return binary.BigEndian.AppendUint32(
+		append(b, MajorSimple<<5|simpleFloat32), v)
+}
+
+// appendFloat64 appends double-precision float of bits v to b.  Unless
+// g.opts.NonCanonical is true, it is encoded in the shortest width
+// which represents it exactly.
+func (g *itemGen) appendFloat64(b []byte, v uint64) []byte {
+	if f, ok := float64To32(v); ok && !g.opts.NonCanonical {
+		return g.appendFloat32(b, f)
+	}
+
+	return binary.BigEndian.AppendUint64(
+		append(b, MajorSimple<<5|simpleFloat64), v)
+}
+
+// float32To16 returns the bits of half-precision float which is equal
+// to single-precision float of bits v.  It returns false if there is no
+// such value.  NaN is converted if dropping the low bits of the payload
+// loses nothing, as RFC 8949 Section 4.1 describes.
+func float32To16(v uint32) (uint16, bool) {
+	sign := uint16(v>>16) & 0x8000
+	exp := int(v>>23&0xff) - 127
+	mant := v & 0x7fffff
+
+	switch {
+	case exp == 128:
+		// Infinity and NaN.
+		if mant&0x1fff != 0 {
+			return 0, false
+		}
+
+		return sign | 0x7c00 | uint16(mant>>13), true
+	case exp == -127 && mant == 0:
+		return sign, true
+	case exp >= -14 && exp <= 15:
+		if mant&0x1fff != 0 {
+			return 0, false
+		}
+
+		return sign | uint16(exp+15)<<10 | uint16(mant>>13), true
+	case exp >= -24 && exp < -14:
+		// Subnormal half-precision float.
+		mant |= 1 << 23
+
+		shift := -exp - 1
+		if mant&(1<<shift-1) != 0 {
+			return 0, false
+		}
+
+		return sign | uint16(mant>>shift), true
+	default:
+		return 0, false
+	}
+}
+
+// float64To32 returns the bits of single-precision float which is equal
+// to double-precision float of bits v.  It returns false if there is no
+// such value.  NaN is converted in the same way as float32To16.
+func float64To32(v uint64) (uint32, bool) {
+	f := math.Float64frombits(v)
+	if !math.IsNaN(f) {
+		g := float32(f)
+
+		return math.Float32bits(g), float64(g) == f
+	}
+
+	if v&(1<<29-1) != 0 {
+		return 0, false
+	}
+
+	return uint32(v>>32)&0x80000000 | 0x7f800000 | uint32(v>>29)&0x7fffff,
+		true
+}
+
+// appendMalformed appends a data item which is not well-formed to b.
+func (g *itemGen) appendMalformed(b []byte) []byte {
+	fdp := g.fdp
+	major := fdp.ConsumeUint8InRange(MajorUint, MajorSimple)
+
+	switch fdp.ConsumeIntInRange(0, 4) {
+	case 0:
+		// Reserved additional information.
+		return append(b, major<<5|fdp.ConsumeUint8InRange(28, 30))
+	case 1:
+		// Break outside of indefinite length item.
+		return append(b, Break)
+	case 2:
+		// Two-byte encoding of simple value less than 32.
+		return append(b, MajorSimple<<5|simpleUint8,
+			fdp.ConsumeUint8InRange(0, 31))
+	case 3:
+		// Indefinite length of integer or tag.
+		ms := []uint8{MajorUint, MajorNegInt, MajorTag}
+
+		return append(b, ms[fdp.ConsumeIntInRange(0, len(ms)-1)]<<5|aiIndefinite)
+	default:
+		// Argument which lacks some of its bytes.  The following
+		// data items are read as the rest of it.
+		n := fdp.ConsumeIntInRange(1, len(argumentMax)-1)
+		v := appendArgument(nil, major, n, fdp.ConsumeUint64())
+
+		return append(b, v[:fdp.ConsumeIntInRange(1, len(v)-1)]...)
+	}
+}
@@ -0,0 +1,237 @@
+package cbor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+
+	fuzz "github.com/ngtcp2/fuzzeddataprovider-go"
+	"github.com/ngtcp2/fuzzeddataprovider-go/internal/gentest"
+)
+
+type reader struct {
+	gentest.Reader
+	// canonical requires preferred serialization.
+	canonical bool
+}
+
+// head returns the major type, the additional information and the
+// argument of the next data item.
+func (r *reader) head() (uint8, uint8, uint64) {
+	c := r.Byte()
+	major, ai := c>>5, c&0x1f
+
+	var v uint64
+
+	switch {
+	case ai < aiUint8:
+		v = uint64(ai)
+	case ai == aiUint8:
+		v = uint64(r.Byte())
+		r.Check(!r.canonical || major == MajorSimple || v >= aiUint8)
+	case ai == aiUint16:
+		v = uint64(binary.BigEndian.Uint16(r.Bytes(2)))
+		r.Check(!r.canonical || major == MajorSimple || v > 0xff)
+	case ai == aiUint32:
+		v = uint64(binary.BigEndian.Uint32(r.Bytes(4)))
+		r.Check(!r.canonical || major == MajorSimple || v > 0xffff)
+	case ai == aiUint64:
+		v = binary.BigEndian.Uint64(r.Bytes(8))
+		r.Check(!r.canonical || major == MajorSimple || v > 0xffffffff)
+	case ai == aiIndefinite:
+		r.Check(!r.canonical && major >= MajorBytes && major <= MajorMap)
+	default:
+		panic(gentest.ErrMalformed)
+	}
+
+	return major, ai, v
+}
+
+// item validates the next data item according to RFC 8949, and
+// returns its encoding.
+func (r *reader) item() []byte {
+	b := r.B
+	major, ai, v := r.head()
+
+	switch major {
+	case MajorBytes, MajorText:
+		if ai != aiIndefinite {
+			r.string(major, v)
+
+			break
+		}
+
+		for !r.peekBreak() {
+			m, cai, n := r.head()
+			r.Check(m == major && cai != aiIndefinite)
+			r.string(major, n)
+		}
+	case MajorArray:
+		for i := uint64(0); ai == aiIndefinite || i < v; i++ {
+			if ai == aiIndefinite && r.peekBreak() {
+				break
+			}
+
+			r.item()
+		}
+	case MajorMap:
+		var keys [][]byte
+
+		for i := uint64(0); ai == aiIndefinite || i < v; i++ {
+			if ai == aiIndefinite && r.peekBreak() {
+				break
+			}
+
+			key := r.item()
+			for _, k := range keys {
+				r.Check(!bytes.Equal(k, key))
+			}
+
+			keys = append(keys, key)
+			r.item()
+		}
+	case MajorTag:
+		if v == TagPosBignum || v == TagNegBignum {
+			m, _, _ := (&reader{Reader: gentest.Reader{B: r.B}}).head()
+			r.Check(m == MajorBytes)
+		}
+
+		r.item()
+	case MajorSimple:
+		switch ai {
+		case aiUint8:
+			r.Check(v >= 32)
+		case aiUint32:
+			r.Check(!r.canonical || !halfFloats[uint32(v)])
+		case aiUint64:
+			r.Check(!r.canonical || !singleFloat(v))
+		}
+	}
+
+	return b[:len(b)-len(r.B)]
+}
+
+// peekBreak consumes the break code if it is next.
+func (r *reader) peekBreak() bool {
+	if r.Peek() != Break {
+		return false
+	}
+
+	r.Byte()
+
+	return true
+}
+
+func (r *reader) string(major uint8, n uint64) {
+	s := r.Bytes(n)
+	r.Check(major != MajorText || utf8.Valid(s))
+}
+
+// halfFloats is the set of the bits of single-precision floats which
+// are equal to half-precision floats.
+var halfFloats = func() map[uint32]bool {
+	m := make(map[uint32]bool)
+
+	for h := range 1 << 16 {
+		sign, exp, mant := h>>15, h>>10&0x1f, h&0x3ff
+
+		var v uint32
+
+		switch exp {
+		case 0x1f:
+			v = uint32(sign)<<31 | 0x7f800000 | uint32(mant)<<13
+		case 0:
+			v = math.Float32bits(float32(math.Ldexp(float64(mant), -24)))
+		default:
+			v = math.Float32bits(
+				float32(math.Ldexp(float64(0x400|mant), exp-25)))
+		}
+
+		m[v|uint32(sign)<<31] = true
+	}
+
+	return m
+}()
+
+// singleFloat returns true if double-precision float of bits v is equal
+// to a single-precision float.
+func singleFloat(v uint64) bool {
+	f := math.Float64frombits(v)
+	if math.IsNaN(f) {
+		return v&(1<<29-1) == 0
+	}
+
+	return float64(float32(f)) == f
+}
+
+// parse validates that b is a single data item.  If canonical is
+// true, it also requires preferred serialization.
+func parse(b []byte, canonical bool) error {
+	return gentest.Parse(func() {
+		r := &reader{Reader: gentest.Reader{B: b}, canonical: canonical}
+		r.item()
+		r.Check(len(r.B) == 0)
+	})
+}
+
+func TestConsumeItem(t *testing.T) {
+	fdp := fuzz.NewFuzzedDataProvider(nil)
+
+	assert.Equal(t, []byte{0x00}, ConsumeItem(fdp, nil))
+
+	// Text string "ab".
+	fdp = fuzz.NewFuzzedDataProvider([]byte{'a', 'b', 0x03})
+
+	assert.Equal(t, []byte{0x62, 'a', 'b'}, ConsumeItem(fdp, nil))
+}
+
+func TestAppendFloat(t *testing.T) {
+	g := &itemGen{opts: &Options{}}
+
+	// Examples from RFC 8949 Appendix A.
+	for _, tc := range []struct {
+		f    float64
+		want []byte
+	}{
+		{0, []byte{0xf9, 0x00, 0x00}},
+		{math.Copysign(0, -1), []byte{0xf9, 0x80, 0x00}},
+		{1.5, []byte{0xf9, 0x3e, 0x00}},
+		{65504, []byte{0xf9, 0x7b, 0xff}},
+		{5.960464477539063e-8, []byte{0xf9, 0x00, 0x01}},
+		{0.00006103515625, []byte{0xf9, 0x04, 0x00}},
+		{-4, []byte{0xf9, 0xc4, 0x00}},
+		{100000, []byte{0xfa, 0x47, 0xc3, 0x50, 0x00}},
+		{3.4028234663852886e+38, []byte{0xfa, 0x7f, 0x7f, 0xff, 0xff}},
+		{1.1, []byte{0xfb, 0x3f, 0xf1, 0x99, 0x99, 0x99, 0x99, 0x99, 0x9a}},
+		{1.0e+300, []byte{0xfb, 0x7e, 0x37, 0xe4, 0x3c, 0x88, 0x00, 0x75, 0x9c}},
+		{math.Inf(-1), []byte{0xf9, 0xfc, 0x00}},
+		{math.Float64frombits(0x7ff8000000000000), []byte{0xf9, 0x7e, 0x00}},
+	} {
+		assert.Equal(t, tc.want, g.appendFloat64(nil, math.Float64bits(tc.f)),
+			"%v", tc.f)
+	}
+
+	// NaN whose payload does not fit in half-precision.
+	assert.Equal(t, []byte{0xfa, 0x7f, 0xc0, 0x00, 0x01},
+		g.appendFloat32(nil, 0x7fc00001))
+
+	g.opts.NonCanonical = true
+
+	assert.Equal(t, []byte{0xfa, 0x3f, 0xc0, 0x00, 0x00},
+		g.appendFloat32(nil, math.Float32bits(1.5)))
+}
+
+func TestConsumeItemProperty(t *testing.T) {
+	for _, nonCanonical := range []bool{false, true} {
+		gentest.Check(t, func(data []byte, illegal bool) []byte {
+			return ConsumeItem(fuzz.NewFuzzedDataProvider(data), &Options{
+				NonCanonical: nonCanonical,
+				AllowIllegal: illegal,
+			})
+		}, func(b []byte) error { return parse(b, !nonCanonical) })
+	}
+}
@@ -0,0 +1,49 @@
+package fuzz
+
+import "math"
+
+// ConsumeWeightedIndex returns an index in the range [0,
+// len(weights)-1] where the probability of each index is proportional
+// to its weight.  An index with zero weight is never returned.  If
+// there is no input data left, it always returns the first index with
+// nonzero weight.  The sum of weights must be nonzero and must not
+// overflow uint64.
+func (fdp *FuzzedDataProvider) ConsumeWeightedIndex(weights []uint) int {
+	var total uint64
+
+	for _, w := range weights {
+		if uint64(w) > math.MaxUint64-total {
+			panic("sum of weights overflows")
+		}
+
+		total += uint64(w)
+	}
+
+	if total == 0 {
+		panic("sum of weights is zero")
+	}
+
+	v := consumeIntegralInRange(fdp, uint64(0), total-1)
+
+	for i, w := range weights {
+		if v < uint64(w) {
+			return i
+		}
+
+		v -= uint64(w)
+	}
+
+	panic("unreachable")
+}
+
+// PickWeighted returns one of values chosen by ConsumeWeightedIndex
+// with weights.  values and weights must have the same length.
+func PickWeighted[T any](
+	fdp *FuzzedDataProvider, values []T, weights []uint,
+) T {
+	if len(values) != len(weights) {
+		panic("len(values) != len(weights)")
+	}
+
+	return values[fdp.ConsumeWeightedIndex(weights)]
+}
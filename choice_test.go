@@ -0,0 +1,43 @@
+package fuzz
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsumeWeightedIndex(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{0x00, 0x09, 0x02, 0x03, 0x05})
+
+	weights := []uint{3, 0, 2, 5}
+
+	assert.Equal(t, 3, fdp.ConsumeWeightedIndex(weights))
+	assert.Equal(t, 2, fdp.ConsumeWeightedIndex(weights))
+	assert.Equal(t, 0, fdp.ConsumeWeightedIndex(weights))
+	assert.Equal(t, 3, fdp.ConsumeWeightedIndex(weights))
+	assert.Equal(t, 0, fdp.ConsumeWeightedIndex(weights))
+	assert.Equal(t, 0, fdp.ConsumeWeightedIndex(weights))
+
+	fdp = NewFuzzedDataProvider([]byte{0x01})
+
+	assert.Equal(t, 2, fdp.ConsumeWeightedIndex([]uint{0, 0, 1}))
+
+	assert.Panics(t, func() { fdp.ConsumeWeightedIndex(nil) })
+	assert.Panics(t, func() { fdp.ConsumeWeightedIndex([]uint{0, 0}) })
+	assert.Panics(t, func() {
+		fdp.ConsumeWeightedIndex([]uint{math.MaxUint, math.MaxUint})
+	})
+}
+
+func TestPickWeighted(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{0x00, 0x63})
+
+	values := []string{"read", "write", "close"}
+	weights := []uint{50, 49, 1}
+
+	assert.Equal(t, "close", PickWeighted(fdp, values, weights))
+	assert.Equal(t, "read", PickWeighted(fdp, values, weights))
+
+	assert.Panics(t, func() { PickWeighted(fdp, values, weights[:2]) })
+}
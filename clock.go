@@ -0,0 +1,198 @@
+package fuzz
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Clock is a fake clock for timer-dependent code.  Its time advances
+// by durations chosen by consuming bytes from the input data, and
+// timers which expire during the same advance fire in the order chosen
+// in the same way.  Timers fire synchronously in the goroutine
+// which advances the time.
+type Clock struct {
+	mu      sync.Mutex
+	fdp     *FuzzedDataProvider
+	now     time.Time
+	maxStep time.Duration
+	timers  []*Timer
+}
+
+// NewClock returns new Clock whose current time is start.  maxStep is
+// the maximum duration by which Now and Sleep advance the time on
+// their own.  A negative maxStep is treated as 0.
+func (fdp *FuzzedDataProvider) NewClock(
+	start time.Time, maxStep time.Duration,
+) *Clock {
+	return &Clock{
+		fdp:     fdp,
+		now:     start,
+		maxStep: max(maxStep, 0),
+	}
+}
+
+// Now advances the time by a duration in the range [0, maxStep] chosen
+// by consuming bytes from the input data, and returns the current
+// time.  If there is no input data left, the time does not advance.
+func (c *Clock) Now() time.Time {
+	return c.advance(0, true)
+}
+
+// Sleep advances the time by d plus a duration in the range [0,
+// maxStep] chosen by consuming bytes from the input data.  It does
+// not block.
+func (c *Clock) Sleep(d time.Duration) {
+	c.advance(max(d, 0), true)
+}
+
+// Advance advances the time by exactly d.
+func (c *Clock) Advance(d time.Duration) {
+	c.advance(max(d, 0), false)
+}
+
+// After returns the channel which receives the current time after d
+// elapses.
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C
+}
+
+// NewTimer returns new Timer which sends the current time on its
+// channel after d elapses.
+func (c *Clock) NewTimer(d time.Duration) *Timer {
+	ch := make(chan time.Time, 1)
+	t := &Timer{
+		C:     ch,
+		c:     ch,
+		clock: c,
+	}
+
+	t.Reset(d)
+
+	return t
+}
+
+// AfterFunc returns new Timer which calls f after d elapses.
+func (c *Clock) AfterFunc(d time.Duration, f func()) *Timer {
+	t := &Timer{
+		clock: c,
+		fn:    f,
+	}
+
+	t.Reset(d)
+
+	return t
+}
+
+// current returns the current time without advancing it.
+func (c *Clock) current() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *Clock) advance(d time.Duration, jitter bool) time.Time {
+	c.mu.Lock()
+
+	if jitter {
+		d += time.Duration(c.fdp.ConsumeInt64InRange(0, int64(c.maxStep)))
+	}
+
+	c.now = c.now.Add(d)
+	now := c.now
+
+	var due []*Timer
+
+	for _, t := range c.timers {
+		if !t.when.After(now) {
+			due = append(due, t)
+		}
+	}
+
+	Shuffle(c.fdp, due)
+
+	c.mu.Unlock()
+
+	for _, t := range due {
+		c.mu.Lock()
+
+		// The timer might have been stopped or reset by the timers
+		// fired earlier.
+		if !t.active || t.when.After(c.now) {
+			c.mu.Unlock()
+
+			continue
+		}
+
+		c.removeLocked(t)
+
+		c.mu.Unlock()
+
+		if t.fn != nil {
+			t.fn()
+
+			continue
+		}
+
+		select {
+		case t.c <- now:
+		default:
+		}
+	}
+
+	return now
+}
+
+func (c *Clock) removeLocked(t *Timer) {
+	t.active = false
+	c.timers = slices.DeleteFunc(c.timers, func(u *Timer) bool {
+		return u == t
+	})
+}
+
+// Timer is a timer created by Clock.
+type Timer struct {
+	// C is the channel on which the time is delivered.  It is nil if
+	// the timer is created by AfterFunc.
+	C <-chan time.Time
+
+	c     chan time.Time
+	clock *Clock
+	fn    func()
+	when  time.Time
+	// active is true if the timer is pending.  It is guarded by
+	// clock.mu.
+	active bool
+}
+
+// Stop prevents the timer from firing.  It returns true if the timer
+// was pending.
+func (t *Timer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	active := t.active
+	if active {
+		t.clock.removeLocked(t)
+	}
+
+	return active
+}
+
+// Reset changes the timer to expire after d.  It returns true if the
+// timer was pending.
+func (t *Timer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	active := t.active
+	t.when = t.clock.now.Add(d)
+
+	if !active {
+		t.active = true
+		t.clock.timers = append(t.clock.timers, t)
+	}
+
+	return active
+}
@@ -0,0 +1,74 @@
+package fuzz
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testClockStart = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestClockNow(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{0x00, 0x01, 0x03})
+	c := fdp.NewClock(testClockStart, 3*time.Nanosecond)
+
+	assert.Equal(t, testClockStart.Add(3), c.Now())
+	assert.Equal(t, testClockStart.Add(4), c.Now())
+
+	c.Sleep(time.Second)
+
+	assert.Equal(t, testClockStart.Add(time.Second+4), c.Now())
+
+	c.Advance(time.Minute)
+
+	assert.Equal(t, testClockStart.Add(time.Minute+time.Second+4), c.Now())
+}
+
+func TestClockNegativeMaxStep(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{0xff})
+	c := fdp.NewClock(testClockStart, -time.Second)
+
+	assert.Equal(t, testClockStart, c.Now())
+
+	c.Sleep(time.Second)
+
+	assert.Equal(t, testClockStart.Add(time.Second), c.Now())
+}
+
+func TestClockTimers(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{0x01, 0x00})
+	c := fdp.NewClock(testClockStart, 0)
+
+	var fired []string
+
+	tm1 := c.AfterFunc(time.Second, func() { fired = append(fired, "a") })
+	c.AfterFunc(2*time.Second, func() { fired = append(fired, "b") })
+	c.AfterFunc(3*time.Second, func() {
+		fired = append(fired, "c")
+
+		tm1.Stop()
+	})
+	tm4 := c.NewTimer(time.Second)
+	ch := c.After(time.Hour)
+
+	assert.True(t, tm4.Stop())
+	assert.False(t, tm4.Stop())
+	assert.False(t, tm4.Reset(time.Second))
+
+	c.Advance(3 * time.Second)
+
+	assert.Equal(t, []string{"c", "b"}, fired)
+	assert.Equal(t, testClockStart.Add(3*time.Second), <-tm4.C)
+	assert.False(t, tm1.Stop())
+
+	select {
+	case <-ch:
+		assert.Fail(t, "timer fired too early")
+	default:
+	}
+
+	c.Sleep(time.Hour)
+
+	assert.Equal(t, testClockStart.Add(time.Hour+3*time.Second), <-ch)
+}
@@ -0,0 +1,63 @@
+package fuzz
+
+// ConsumeSlice returns slice of length from 0 to maxLen whose elements
+// are produced by gen.  Before each element, it consumes one byte, and
+// a zero byte terminates the slice.  Because the length is not
+// decided up front, inserting or removing bytes in the middle of the
+// input does not change the number of preceding elements.  When it
+// runs out of input data, it returns the elements generated so far.
+func ConsumeSlice[T any](
+	fdp *FuzzedDataProvider, maxLen int, gen func(*FuzzedDataProvider) T,
+) []T {
+	var res []T
+
+	for len(res) < maxLen && fdp.ConsumeUint8() != 0 {
+		res = append(res, gen(fdp))
+	}
+
+	return res
+}
+
+// ConsumeMap returns map with at most maxLen entries whose keys and
+// values are produced by genKey and genValue respectively.  The
+// number of entries is chosen in the same way as ConsumeSlice.  If
+// genKey returns a key which is already present, its value is
+// overwritten, and the map ends up with fewer entries.
+func ConsumeMap[K comparable, V any](
+	fdp *FuzzedDataProvider, maxLen int,
+	genKey func(*FuzzedDataProvider) K,
+	genValue func(*FuzzedDataProvider) V,
+) map[K]V {
+	res := make(map[K]V)
+
+	for range maxLen {
+		if fdp.ConsumeUint8() == 0 {
+			break
+		}
+
+		k := genKey(fdp)
+		res[k] = genValue(fdp)
+	}
+
+	return res
+}
+
+// ConsumeSet returns set with at most maxLen elements produced by
+// gen.  The number of elements is chosen in the same way as
+// ConsumeSlice.  Duplicate elements are collapsed, and the set ends
+// up with fewer elements.
+func ConsumeSet[T comparable](
+	fdp *FuzzedDataProvider, maxLen int, gen func(*FuzzedDataProvider) T,
+) map[T]struct{} {
+	res := make(map[T]struct{})
+
+	for range maxLen {
+		if fdp.ConsumeUint8() == 0 {
+			break
+		}
+
+		res[gen(fdp)] = struct{}{}
+	}
+
+	return res
+}
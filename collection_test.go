@@ -0,0 +1,41 @@
+package fuzz
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsumeSlice(t *testing.T) {
+	fdp := NewFuzzedDataProvider(
+		[]byte{0xba, 0xad, 0xf0, 0x00, 0xde, 0xad, 0xbe, 0xef})
+
+	assert.Equal(t, []uint8{0xbe, 0xde},
+		ConsumeSlice(fdp, 10, (*FuzzedDataProvider).ConsumeUint8))
+	assert.Equal(t, []uint8{0xad},
+		ConsumeSlice(fdp, 1, (*FuzzedDataProvider).ConsumeUint8))
+	assert.Equal(t, []uint8{0x00},
+		ConsumeSlice(fdp, 10, (*FuzzedDataProvider).ConsumeUint8))
+	assert.Nil(t, ConsumeSlice(fdp, 10, (*FuzzedDataProvider).ConsumeUint8))
+}
+
+func TestConsumeMap(t *testing.T) {
+	fdp := NewFuzzedDataProvider(
+		[]byte{0x00, 0x02, 0x01, 0x01, 0x02, 0x02, 0x01, 0x01, 0x01})
+
+	assert.Equal(t, map[uint8]bool{0x01: true, 0x02: false},
+		ConsumeMap(fdp, 10, (*FuzzedDataProvider).ConsumeUint8,
+			(*FuzzedDataProvider).ConsumeBool))
+	assert.Empty(t, ConsumeMap(fdp, 10, (*FuzzedDataProvider).ConsumeUint8,
+		(*FuzzedDataProvider).ConsumeBool))
+}
+
+func TestConsumeSet(t *testing.T) {
+	fdp := NewFuzzedDataProvider(
+		[]byte{0x02, 0x01, 0x01, 0x01, 0x02, 0x01, 0x01, 0x01})
+
+	assert.Equal(t, map[uint8]struct{}{0x01: {}, 0x02: {}},
+		ConsumeSet(fdp, 3, (*FuzzedDataProvider).ConsumeUint8))
+	assert.Equal(t, map[uint8]struct{}{0x02: {}},
+		ConsumeSet(fdp, 3, (*FuzzedDataProvider).ConsumeUint8))
+}
@@ -0,0 +1,419 @@
+package fuzz
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	deliverInOrder = iota
+	deliverDrop
+	deliverDuplicate
+	deliverReorder
+	deliverTruncate
+)
+
+// deliverWeights are the weights of deliverInOrder, deliverDrop,
+// deliverDuplicate, deliverReorder and deliverTruncate.
+var deliverWeights = []uint{12, 1, 1, 1, 1}
+
+const (
+	deadlineWait = iota
+	deadlineTimeout
+)
+
+// deadlineWeights are the weights of deadlineWait and deadlineTimeout.
+var deadlineWeights = []uint{15, 1}
+
+// errMissingAddress is returned by WriteTo without destination
+// address, as net.UDPConn does.
+var errMissingAddress = errors.New("missing address")
+
+// netPipe is the state shared by a pair of connections.
+type netPipe struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	fdp  *FuzzedDataProvider
+	// clock measures deadlines.  If it is nil, deadlines expire only
+	// as chosen by consuming bytes from the input data.
+	clock *Clock
+}
+
+type packet struct {
+	b    []byte
+	addr net.Addr
+}
+
+// endpoint is one side of a connection pair.  All fields are guarded
+// by p.mu.
+type endpoint struct {
+	p             *netPipe
+	peer          *endpoint
+	laddr         net.Addr
+	errClosed     error
+	closed        bool
+	readDeadline  time.Time
+	writeDeadline time.Time
+	readTimer     *Timer
+	// packets is the queue of datagrams received by a packet
+	// connection.
+	packets []packet
+	// buf is the bytes received by a stream connection.
+	buf []byte
+}
+
+func newEndpointPair(
+	fdp *FuzzedDataProvider, clock *Clock, addrA, addrB net.Addr,
+	errClosed error,
+) (*endpoint, *endpoint) {
+	p := &netPipe{
+		fdp:   fdp,
+		clock: clock,
+	}
+	p.cond = sync.NewCond(&p.mu)
+
+	a := &endpoint{
+		p:         p,
+		laddr:     addrA,
+		errClosed: errClosed,
+	}
+	b := &endpoint{
+		p:         p,
+		laddr:     addrB,
+		errClosed: errClosed,
+	}
+	a.peer = b
+	b.peer = a
+
+	return a, b
+}
+
+// expired returns true if deadline has passed.  Without clock, a
+// deadline passes only when the operation would block.  It must be
+// called with e.p.mu held.
+func (e *endpoint) expired(deadline time.Time, blocking bool) bool {
+	switch {
+	case deadline.IsZero():
+		return false
+	case e.p.clock == nil:
+		return blocking
+	default:
+		return !e.p.clock.current().Before(deadline)
+	}
+}
+
+// timeout returns true if an operation with deadline set times out
+// early as chosen by consuming bytes from the input data.  It must be
+// called with e.p.mu held.
+func (e *endpoint) timeout(deadline time.Time) bool {
+	return !deadline.IsZero() &&
+		e.p.fdp.ConsumeWeightedIndex(deadlineWeights) == deadlineTimeout
+}
+
+// waitReadable waits until ready returns true.  It must be called with
+// e.p.mu held.
+func (e *endpoint) waitReadable(ready func() bool) error {
+	if e.closed {
+		return e.errClosed
+	}
+
+	if e.timeout(e.readDeadline) {
+		return os.ErrDeadlineExceeded
+	}
+
+	for {
+		switch {
+		case e.closed:
+			return e.errClosed
+		case ready():
+			return nil
+		case e.expired(e.readDeadline, true):
+			return os.ErrDeadlineExceeded
+		}
+
+		e.p.cond.Wait()
+	}
+}
+
+// checkWritable returns an error if e cannot be written.  It must be
+// called with e.p.mu held.
+func (e *endpoint) checkWritable() error {
+	if e.closed {
+		return e.errClosed
+	}
+
+	if e.expired(e.writeDeadline, false) || e.timeout(e.writeDeadline) {
+		return os.ErrDeadlineExceeded
+	}
+
+	return nil
+}
+
+func (e *endpoint) Close() error {
+	e.p.mu.Lock()
+	defer e.p.mu.Unlock()
+
+	if e.closed {
+		return e.errClosed
+	}
+
+	e.closed = true
+
+	if e.readTimer != nil {
+		e.readTimer.Stop()
+	}
+
+	e.p.cond.Broadcast()
+
+	return nil
+}
+
+func (e *endpoint) LocalAddr() net.Addr {
+	return e.laddr
+}
+
+func (e *endpoint) SetDeadline(t time.Time) error {
+	if err := e.SetReadDeadline(t); err != nil {
+		return err
+	}
+
+	return e.SetWriteDeadline(t)
+}
+
+func (e *endpoint) SetReadDeadline(t time.Time) error {
+	e.p.mu.Lock()
+	defer e.p.mu.Unlock()
+
+	if e.closed {
+		return e.errClosed
+	}
+
+	e.readDeadline = t
+
+	if e.readTimer != nil {
+		e.readTimer.Stop()
+		e.readTimer = nil
+	}
+
+	// Wake up the blocked reads when the clock reaches the deadline.
+	if clock := e.p.clock; clock != nil && !t.IsZero() {
+		e.readTimer = clock.AfterFunc(t.Sub(clock.current()), func() {
+			e.p.mu.Lock()
+			e.p.cond.Broadcast()
+			e.p.mu.Unlock()
+		})
+	}
+
+	e.p.cond.Broadcast()
+
+	return nil
+}
+
+func (e *endpoint) SetWriteDeadline(t time.Time) error {
+	e.p.mu.Lock()
+	defer e.p.mu.Unlock()
+
+	if e.closed {
+		return e.errClosed
+	}
+
+	e.writeDeadline = t
+
+	return nil
+}
+
+type packetConn struct {
+	*endpoint
+}
+
+// NewPacketConnPair returns a pair of in-memory net.PacketConn bound
+// to addrA and addrB respectively.  A datagram written to the address
+// of the other side is delivered in order, dropped, duplicated,
+// reordered or truncated as chosen by consuming bytes from the input
+// data.  A datagram written to any other address is silently
+// discarded.  An operation with a deadline set may time out early as
+// chosen in the same way.  The time values of deadlines are not
+// measured, and a read with a deadline set times out instead of
+// blocking.  If there is no input data left, datagrams are delivered
+// in order.  Operations on closed connection return net.ErrClosed.
+//
+// The connections share fdp, and access to it is serialized.  The
+// behavior is deterministic only if the connections are used from a
+// single goroutine.  Reads without a deadline block until a datagram
+// arrives.
+func (fdp *FuzzedDataProvider) NewPacketConnPair(
+	addrA, addrB net.Addr,
+) (net.PacketConn, net.PacketConn) {
+	return newPacketConnPair(fdp, nil, addrA, addrB)
+}
+
+// NewPacketConnPair is like FuzzedDataProvider.NewPacketConnPair, but
+// deadlines are measured by c.  A read blocks until a datagram arrives
+// or c reaches the read deadline, which requires another goroutine to
+// advance c.  Access to fdp by c is not serialized with the
+// connections.
+func (c *Clock) NewPacketConnPair(
+	addrA, addrB net.Addr,
+) (net.PacketConn, net.PacketConn) {
+	return newPacketConnPair(c.fdp, c, addrA, addrB)
+}
+
+func newPacketConnPair(
+	fdp *FuzzedDataProvider, clock *Clock, addrA, addrB net.Addr,
+) (net.PacketConn, net.PacketConn) {
+	a, b := newEndpointPair(fdp, clock, addrA, addrB, net.ErrClosed)
+
+	return &packetConn{a}, &packetConn{b}
+}
+
+func (c *packetConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	c.p.mu.Lock()
+	defer c.p.mu.Unlock()
+
+	if err := c.waitReadable(func() bool {
+		return len(c.packets) != 0
+	}); err != nil {
+		return 0, nil, err
+	}
+
+	pkt := c.packets[0]
+	c.packets = c.packets[1:]
+
+	return copy(p, pkt.b), pkt.addr, nil
+}
+
+func (c *packetConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	c.p.mu.Lock()
+	defer c.p.mu.Unlock()
+
+	if err := c.checkWritable(); err != nil {
+		return 0, err
+	}
+
+	if addr == nil {
+		return 0, &net.OpError{
+			Op:     "write",
+			Net:    c.laddr.Network(),
+			Source: c.laddr,
+			Err:    errMissingAddress,
+		}
+	}
+
+	peer := c.peer
+	if peer.closed || addr.String() != peer.laddr.String() {
+		return len(b), nil
+	}
+
+	fdp := c.p.fdp
+	pkt := packet{
+		b:    slices.Clone(b),
+		addr: c.laddr,
+	}
+
+	switch fdp.ConsumeWeightedIndex(deliverWeights) {
+	case deliverDrop:
+		return len(b), nil
+	case deliverDuplicate:
+		peer.packets = append(peer.packets, pkt, pkt)
+	case deliverReorder:
+		peer.packets = slices.Insert(peer.packets,
+			fdp.ConsumeIntInRange(0, len(peer.packets)), pkt)
+	case deliverTruncate:
+		pkt.b = pkt.b[:fdp.ConsumeIntInRange(0, len(pkt.b))]
+		peer.packets = append(peer.packets, pkt)
+	default:
+		peer.packets = append(peer.packets, pkt)
+	}
+
+	c.p.cond.Broadcast()
+
+	return len(b), nil
+}
+
+type streamConn struct {
+	*endpoint
+}
+
+// NewConnPair returns a pair of in-memory net.Conn whose local
+// addresses are addrA and addrB respectively.  Like net.Pipe, bytes
+// written to one side can be read from the other side, but the number
+// of bytes returned by each Read is chosen by consuming bytes from the
+// input data.  Deadlines work in the same way as NewPacketConnPair.
+// If there is no input data left, each Read returns 1 byte.  Writes
+// never block.  Operations on closed connection return
+// io.ErrClosedPipe, and Read returns io.EOF once the other side is
+// closed and all bytes are read.
+//
+// The connections share fdp in the same way as NewPacketConnPair.
+func (fdp *FuzzedDataProvider) NewConnPair(
+	addrA, addrB net.Addr,
+) (net.Conn, net.Conn) {
+	return newConnPair(fdp, nil, addrA, addrB)
+}
+
+// NewConnPair is like FuzzedDataProvider.NewConnPair, but deadlines are
+// measured by c in the same way as Clock.NewPacketConnPair.
+func (c *Clock) NewConnPair(addrA, addrB net.Addr) (net.Conn, net.Conn) {
+	return newConnPair(c.fdp, c, addrA, addrB)
+}
+
+func newConnPair(
+	fdp *FuzzedDataProvider, clock *Clock, addrA, addrB net.Addr,
+) (net.Conn, net.Conn) {
+	a, b := newEndpointPair(fdp, clock, addrA, addrB, io.ErrClosedPipe)
+
+	return &streamConn{a}, &streamConn{b}
+}
+
+func (c *streamConn) Read(p []byte) (int, error) {
+	c.p.mu.Lock()
+	defer c.p.mu.Unlock()
+
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if err := c.waitReadable(func() bool {
+		return len(c.buf) != 0 || c.peer.closed
+	}); err != nil {
+		return 0, err
+	}
+
+	if len(c.buf) == 0 {
+		return 0, io.EOF
+	}
+
+	n := c.p.fdp.ConsumeIntInRange(1, min(len(p), len(c.buf)))
+	copy(p, c.buf[:n])
+	c.buf = c.buf[n:]
+
+	return n, nil
+}
+
+func (c *streamConn) Write(b []byte) (int, error) {
+	c.p.mu.Lock()
+	defer c.p.mu.Unlock()
+
+	if err := c.checkWritable(); err != nil {
+		return 0, err
+	}
+
+	if c.peer.closed {
+		return 0, io.ErrClosedPipe
+	}
+
+	c.peer.buf = append(c.peer.buf, b...)
+
+	c.p.cond.Broadcast()
+
+	return len(b), nil
+}
+
+func (c *streamConn) RemoteAddr() net.Addr {
+	return c.peer.laddr
+}
@@ -0,0 +1,207 @@
+package fuzz
+
+import (
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testAddrA = net.UDPAddrFromAddrPort(
+		netip.MustParseAddrPort("127.0.0.1:4433"))
+	testAddrB = net.UDPAddrFromAddrPort(
+		netip.MustParseAddrPort("127.0.0.1:4434"))
+)
+
+func readPacket(t *testing.T, c net.PacketConn) string {
+	t.Helper()
+
+	buf := make([]byte, 16)
+
+	n, addr, err := c.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Equal(t, testAddrA.String(), addr.String())
+
+	return string(buf[:n])
+}
+
+func TestPacketConnPair(t *testing.T) {
+	fdp := NewFuzzedDataProvider(
+		[]byte{0x02, 0x0f, 0x00, 0x0e, 0x0d, 0x0c, 0x00})
+	a, b := fdp.NewPacketConnPair(testAddrA, testAddrB)
+
+	assert.Equal(t, testAddrA, a.LocalAddr())
+
+	for _, s := range []string{"one", "two", "three", "four", "five"} {
+		n, err := a.WriteTo([]byte(s), testAddrB)
+
+		require.NoError(t, err)
+		assert.Equal(t, len(s), n)
+	}
+
+	n, err := a.WriteTo([]byte("lost"), testAddrA)
+
+	require.NoError(t, err)
+	assert.Equal(t, 4, n)
+
+	assert.Equal(t, "four", readPacket(t, b))
+	assert.Equal(t, "one", readPacket(t, b))
+	assert.Equal(t, "three", readPacket(t, b))
+	assert.Equal(t, "three", readPacket(t, b))
+	assert.Equal(t, "fi", readPacket(t, b))
+
+	require.NoError(t, b.SetReadDeadline(time.Now().Add(-time.Second)))
+
+	_, _, err = b.ReadFrom(make([]byte, 16))
+
+	require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+
+	require.NoError(t, b.Close())
+
+	_, _, err = b.ReadFrom(make([]byte, 16))
+
+	require.ErrorIs(t, err, net.ErrClosed)
+	require.ErrorIs(t, b.Close(), net.ErrClosed)
+
+	n, err = a.WriteTo([]byte("closed"), testAddrB)
+
+	require.NoError(t, err)
+	assert.Equal(t, 6, n)
+}
+
+func TestPacketConnPairReadDeadline(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{0x0f, 0x00})
+	a, b := fdp.NewPacketConnPair(testAddrA, testAddrB)
+
+	_, err := a.WriteTo([]byte("one"), testAddrB)
+
+	require.NoError(t, err)
+	require.NoError(t, b.SetReadDeadline(time.Now().Add(time.Hour)))
+
+	_, _, err = b.ReadFrom(make([]byte, 16))
+
+	require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+
+	require.NoError(t, b.SetReadDeadline(time.Now().Add(time.Millisecond)))
+
+	assert.Equal(t, "one", readPacket(t, b))
+
+	_, _, err = b.ReadFrom(make([]byte, 16))
+
+	require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+}
+
+func TestConnPair(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{0x01, 0x02})
+	a, b := fdp.NewConnPair(testAddrA, testAddrB)
+
+	assert.Equal(t, testAddrA, a.LocalAddr())
+	assert.Equal(t, testAddrB, a.RemoteAddr())
+
+	n, err := a.Write([]byte("foo bar"))
+
+	require.NoError(t, err)
+	assert.Equal(t, 7, n)
+
+	buf := make([]byte, 4)
+
+	n, err = b.Read(buf)
+
+	require.NoError(t, err)
+	assert.Equal(t, "foo", string(buf[:n]))
+
+	n, err = b.Read(buf)
+
+	require.NoError(t, err)
+	assert.Equal(t, " b", string(buf[:n]))
+
+	require.NoError(t, a.Close())
+
+	rest, err := io.ReadAll(b)
+
+	require.NoError(t, err)
+	assert.Equal(t, "ar", string(rest))
+
+	_, err = b.Write([]byte("foo"))
+
+	require.ErrorIs(t, err, io.ErrClosedPipe)
+
+	_, err = a.Read(buf)
+
+	require.ErrorIs(t, err, io.ErrClosedPipe)
+}
+
+func TestPacketConnPairMissingAddress(t *testing.T) {
+	fdp := NewFuzzedDataProvider(nil)
+	a, _ := fdp.NewPacketConnPair(testAddrA, testAddrB)
+
+	_, err := a.WriteTo([]byte("foo"), nil)
+
+	var opErr *net.OpError
+
+	require.ErrorAs(t, err, &opErr)
+	assert.Equal(t, "write", opErr.Op)
+	assert.Equal(t, testAddrA, opErr.Source)
+}
+
+func TestConnPairWriteDeadline(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{0x00, 0x0f})
+	a, _ := fdp.NewConnPair(testAddrA, testAddrB)
+
+	// The time value of the deadline is not measured.
+	require.NoError(t, a.SetDeadline(time.Now().Add(-time.Second)))
+
+	_, err := a.Write([]byte("foo"))
+
+	require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+
+	n, err := a.Write([]byte("foo"))
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+}
+
+func TestClockConnPairDeadline(t *testing.T) {
+	fdp := NewFuzzedDataProvider(nil)
+	c := fdp.NewClock(testClockStart, 0)
+	a, b := c.NewConnPair(testAddrA, testAddrB)
+
+	require.NoError(t, a.SetWriteDeadline(testClockStart.Add(time.Second)))
+
+	_, err := a.Write([]byte("foo"))
+
+	require.NoError(t, err)
+
+	c.Advance(time.Second)
+
+	_, err = a.Write([]byte("bar"))
+
+	require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+
+	buf := make([]byte, 4)
+
+	require.NoError(t, b.SetReadDeadline(testClockStart.Add(2*time.Second)))
+
+	rest, err := io.ReadAll(io.LimitReader(b, 3))
+
+	require.NoError(t, err)
+	assert.Equal(t, "foo", string(rest))
+
+	// The blocked read times out when the clock reaches the deadline.
+	errc := make(chan error)
+
+	go func() {
+		_, err := b.Read(buf)
+		errc <- err
+	}()
+
+	c.Advance(time.Second)
+
+	require.ErrorIs(t, <-errc, os.ErrDeadlineExceeded)
+}
@@ -0,0 +1,229 @@
+package fuzz
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrInvalidDictionary is returned by ParseDictionary when an entry
+// does not follow the AFL/libFuzzer dictionary syntax.
+var ErrInvalidDictionary = errors.New("invalid dictionary entry")
+
+// ParseDictionary parses a dictionary in the AFL/libFuzzer .dict
+// format from r and returns its tokens in order.  Each non-empty line
+// which does not start with '#' must have the form name="value",
+// name@level="value" or "value".  name consists of letters, digits,
+// '_' and '-', level consists of digits, and '=' may be surrounded by
+// spaces.  The value may contain the escape sequences \\, \" and
+// \xNN.
+func ParseDictionary(r io.Reader) ([]string, error) {
+	var tokens []string
+
+	sc := bufio.NewScanner(r)
+
+	for lineno := 1; sc.Scan(); lineno++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		token, err := parseDictionaryEntry(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineno, err)
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func parseDictionaryEntry(line string) (string, error) {
+	start := strings.IndexByte(line, '"')
+	if start == -1 || start == len(line)-1 || line[len(line)-1] != '"' ||
+		!validDictionaryName(line[:start]) {
+		return "", ErrInvalidDictionary
+	}
+
+	s := line[start+1 : len(line)-1]
+
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if s[i] == '"' {
+			return "", ErrInvalidDictionary
+		}
+
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+
+			continue
+		}
+
+		i++
+
+		if i == len(s) {
+			return "", ErrInvalidDictionary
+		}
+
+		switch s[i] {
+		case '\\', '"':
+			b.WriteByte(s[i])
+		case 'x':
+			if i+2 >= len(s) {
+				return "", ErrInvalidDictionary
+			}
+
+			hi, lo := unhex(s[i+1]), unhex(s[i+2])
+			if hi < 0 || lo < 0 {
+				return "", ErrInvalidDictionary
+			}
+
+			b.WriteByte(byte(hi<<4 | lo))
+
+			i += 2
+		default:
+			return "", ErrInvalidDictionary
+		}
+	}
+
+	return b.String(), nil
+}
+
+// validDictionaryName returns true if s is empty or has the form
+// name=, name@level= with optional spaces around '='.
+func validDictionaryName(s string) bool {
+	if s == "" {
+		return true
+	}
+
+	s, ok := strings.CutSuffix(strings.TrimRight(s, " \t"), "=")
+	if !ok {
+		return false
+	}
+
+	name, level, hasLevel := strings.Cut(strings.TrimRight(s, " \t"), "@")
+	if name == "" || hasLevel && level == "" {
+		return false
+	}
+
+	for _, c := range []byte(name) {
+		if !isAlnum(c) && c != '_' && c != '-' {
+			return false
+		}
+	}
+
+	for _, c := range []byte(level) {
+		if !isDigit(c) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isAlnum(c byte) bool {
+	return isDigit(c) || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func unhex(c byte) int {
+	switch {
+	case '0' <= c && c <= '9':
+		return int(c - '0')
+	case 'a' <= c && c <= 'f':
+		return int(c - 'a' + 10)
+	case 'A' <= c && c <= 'F':
+		return int(c - 'A' + 10)
+	default:
+		return -1
+	}
+}
+
+// SetDictionary attaches tokens to fdp.  They are used by ConsumeToken,
+// ConsumeDictionaryString, and the string and byte consumers which
+// take a length.  Passing nil detaches the dictionary.
+func (fdp *FuzzedDataProvider) SetDictionary(tokens []string) {
+	fdp.dict = tokens
+}
+
+// Dictionary returns the tokens attached by SetDictionary.
+func (fdp *FuzzedDataProvider) Dictionary() []string {
+	return fdp.dict
+}
+
+// ConsumeToken returns one of the dictionary tokens chosen by
+// consuming bytes from the input data.  If there is no input data
+// left, it always returns the first token.  If no dictionary is
+// attached, it returns an empty string without consuming any data.
+func (fdp *FuzzedDataProvider) ConsumeToken() string {
+	if len(fdp.dict) == 0 {
+		return ""
+	}
+
+	return fdp.dict[fdp.ConsumeIntInRange(0, len(fdp.dict)-1)]
+}
+
+// ConsumeDictionaryString returns string of length from 0 to
+// maxLength which is built by splicing dictionary tokens between
+// random length strings consumed in the same way as
+// ConsumeRandomLengthString without dictionary.  A token which does
+// not fit in the remaining length is skipped.  If no dictionary is
+// attached, it is equivalent to ConsumeRandomLengthString.
+func (fdp *FuzzedDataProvider) ConsumeDictionaryString(maxLength int) string {
+	if len(fdp.dict) == 0 {
+		return fdp.consumeRandomLengthString(maxLength)
+	}
+
+	var result strings.Builder
+
+	for result.Len() < maxLength && len(fdp.data) != 0 {
+		if fdp.ConsumeBool() {
+			token := fdp.ConsumeToken()
+			if result.Len()+len(token) <= maxLength {
+				result.WriteString(token)
+			}
+
+			continue
+		}
+
+		result.WriteString(
+			fdp.consumeRandomLengthString(maxLength - result.Len()))
+	}
+
+	return result.String()
+}
+
+// consumeDictionaryBytes returns up to n bytes built by splicing
+// dictionary tokens between chunks of input data whose lengths are
+// chosen by consuming bytes from the input data.  A token which does
+// not fit in the remaining length is skipped.  It returns fewer than n
+// bytes only if the input data run out.
+func (fdp *FuzzedDataProvider) consumeDictionaryBytes(n int) []byte {
+	var b []byte
+
+	for len(b) < n && len(fdp.data) != 0 {
+		if fdp.ConsumeBool() {
+			token := fdp.ConsumeToken()
+			if len(b)+len(token) <= n {
+				b = append(b, token...)
+			}
+
+			continue
+		}
+
+		b = append(b, fdp.consumeBytes(fdp.ConsumeIntInRange(1, n-len(b)))...)
+	}
+
+	return b
+}
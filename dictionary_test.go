@@ -0,0 +1,94 @@
+package fuzz
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDictionary(t *testing.T) {
+	tokens, err := ParseDictionary(strings.NewReader(`# comment
+
+kw1="GET"
+kw2@1="\x00\xffA"
+  "quote\"back\\slash"
+kw_3-a = "POST"
+`))
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"GET", "\x00\xffA", "quote\"back\\slash", "POST",
+	}, tokens)
+
+	for _, s := range []string{
+		`kw1=GET`,
+		`kw1="GET`,
+		`"`,
+		`"\x0"`,
+		`"\xzz"`,
+		`"\n"`,
+		`"\"`,
+		`"a"b"`,
+		`junk "GET"`,
+		`kw1"GET"`,
+		`kw 1="GET"`,
+		`kw1@="GET"`,
+		`kw1@x="GET"`,
+		`@1="GET"`,
+	} {
+		_, err := ParseDictionary(strings.NewReader(s))
+
+		assert.ErrorIs(t, err, ErrInvalidDictionary, s)
+	}
+}
+
+func TestConsumeToken(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{0x01, 0x02})
+
+	assert.Empty(t, fdp.ConsumeToken())
+	assert.Equal(t, 2, fdp.RemainingBytes())
+
+	fdp.SetDictionary([]string{"alpha", "bravo", "charlie"})
+
+	assert.Equal(t, "charlie", fdp.ConsumeToken())
+	assert.Equal(t, "bravo", fdp.ConsumeToken())
+	assert.Equal(t, "alpha", fdp.ConsumeToken())
+}
+
+func TestConsumeDictionaryString(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte("foo\\Xbar\x00\x01\x01\x01"))
+	fdp.SetDictionary([]string{"alpha", "bravo"})
+
+	assert.Equal(t, "bravoalphafoo", fdp.ConsumeDictionaryString(13))
+	assert.Equal(t, "alpha\\", fdp.ConsumeDictionaryString(100))
+	assert.Empty(t, fdp.ConsumeDictionaryString(100))
+
+	fdp = NewFuzzedDataProvider([]byte("foo bar"))
+
+	assert.Equal(t, "foo", fdp.ConsumeDictionaryString(3))
+}
+
+func TestConsumeRandomLengthStringDictionary(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte("foo\\Xbar\x00\x01\x01\x01"))
+	fdp.SetDictionary([]string{"alpha", "bravo"})
+
+	assert.Equal(t, "bravoalphafoo", fdp.ConsumeRandomLengthString(13))
+}
+
+func TestConsumeBytesDictionary(t *testing.T) {
+	// The token, a 2-byte chunk, the token which does not fit, and a
+	// 1-byte chunk.
+	fdp := NewFuzzedDataProvider([]byte("abcdef\x00\x01\x01\x00\x01"))
+	fdp.SetDictionary([]string{"XY"})
+
+	assert.Equal(t, []byte("XYabc"), fdp.ConsumeBytes(5))
+	assert.Equal(t, []byte("def"), fdp.ConsumeRemainingBytes())
+
+	fdp = NewFuzzedDataProvider([]byte("abc\x01"))
+	fdp.SetDictionary([]string{"XY"})
+
+	assert.Equal(t, "XY", fdp.ConsumeBytesAsString(2))
+	assert.Equal(t, 3, fdp.RemainingBytes())
+}
@@ -0,0 +1,117 @@
+package fuzz
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type operation struct {
+	name string
+	fn   func(*FuzzedDataProvider) error
+}
+
+// Driver runs a sequence of registered operations chosen by consuming
+// bytes from the input data.  It is intended for fuzzing stateful
+// APIs, replacing the loop which switches on ConsumeIntInRange until
+// the input data is exhausted.
+type Driver struct {
+	fdp      *FuzzedDataProvider
+	ops      []operation
+	weights  []uint
+	maxSteps int
+	trace    []string
+}
+
+// NewDriver returns new Driver which consumes fdp.
+func NewDriver(fdp *FuzzedDataProvider) *Driver {
+	return &Driver{
+		fdp: fdp,
+	}
+}
+
+// Register registers fn as an operation named name with weight 1.
+func (d *Driver) Register(name string, fn func(*FuzzedDataProvider) error) {
+	d.RegisterWeighted(name, 1, fn)
+}
+
+// RegisterWeighted registers fn as an operation named name.  The
+// probability that the operation is picked at each step is
+// proportional to weight.  See ConsumeWeightedIndex.
+func (d *Driver) RegisterWeighted(
+	name string, weight uint, fn func(*FuzzedDataProvider) error,
+) {
+	d.ops = append(d.ops, operation{
+		name: name,
+		fn:   fn,
+	})
+	d.weights = append(d.weights, weight)
+}
+
+// SetMaxSteps sets the maximum number of operations Run executes.  0
+// means no limit, which is the default.
+func (d *Driver) SetMaxSteps(n int) {
+	d.maxSteps = n
+}
+
+// Run repeatedly picks one of the registered operations and executes
+// it until the input data is exhausted or the step budget set by
+// SetMaxSteps is hit.  It also stops if a step consumes no input
+// data, because the same operation would be picked forever.  If an
+// operation returns an error, Run stops and returns *StepError which
+// wraps it.  At least one operation must be registered.
+func (d *Driver) Run() error {
+	if len(d.ops) == 0 {
+		panic("no operations registered")
+	}
+
+	for step := 0; d.fdp.RemainingBytes() != 0 &&
+		(d.maxSteps == 0 || step < d.maxSteps); step++ {
+		rem := d.fdp.RemainingBytes()
+		op := &d.ops[d.fdp.ConsumeWeightedIndex(d.weights)]
+
+		d.trace = append(d.trace, op.name)
+
+		if err := op.fn(d.fdp); err != nil {
+			return &StepError{
+				Step:  step,
+				Name:  op.name,
+				Trace: d.Trace(),
+				Err:   err,
+			}
+		}
+
+		if rem == d.fdp.RemainingBytes() {
+			break
+		}
+	}
+
+	return nil
+}
+
+// Trace returns the names of the operations executed so far in order.
+func (d *Driver) Trace() []string {
+	return slices.Clone(d.trace)
+}
+
+// StepError is returned by Driver.Run when an operation fails.
+type StepError struct {
+	// Step is the 0-based index of the failed step.
+	Step int
+	// Name is the name of the failed operation.
+	Name string
+	// Trace is the names of the operations executed, including the
+	// failed one.
+	Trace []string
+	// Err is the error returned by the operation.
+	Err error
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("step %d (%s): %v; trace: %s", e.Step, e.Name, e.Err,
+		strings.Join(e.Trace, ", "))
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,80 @@
+package fuzz
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDriverRun(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{0x07, 0x01, 0x00, 0x02, 0x01})
+	d := NewDriver(fdp)
+
+	var written []uint8
+
+	d.Register("open", func(*FuzzedDataProvider) error { return nil })
+	d.Register("write", func(fdp *FuzzedDataProvider) error {
+		written = append(written, fdp.ConsumeUint8())
+
+		return nil
+	})
+	d.RegisterWeighted("close", 0, func(*FuzzedDataProvider) error {
+		return errors.New("unexpected close")
+	})
+
+	require.NoError(t, d.Run())
+	assert.Equal(t, []string{"write", "open", "write"}, d.Trace())
+	assert.Equal(t, []uint8{0x02, 0x07}, written)
+	assert.Zero(t, fdp.RemainingBytes())
+}
+
+func TestDriverRunError(t *testing.T) {
+	errClosed := errors.New("closed")
+
+	fdp := NewFuzzedDataProvider([]byte{0x01, 0x00})
+	d := NewDriver(fdp)
+
+	d.Register("write", func(*FuzzedDataProvider) error { return nil })
+	d.Register("close", func(*FuzzedDataProvider) error { return errClosed })
+
+	err := d.Run()
+
+	var stepErr *StepError
+
+	require.ErrorAs(t, err, &stepErr)
+	require.ErrorIs(t, err, errClosed)
+	assert.Equal(t, 1, stepErr.Step)
+	assert.Equal(t, "close", stepErr.Name)
+	assert.Equal(t, []string{"write", "close"}, stepErr.Trace)
+	assert.Equal(t, "step 1 (close): closed; trace: write, close",
+		err.Error())
+}
+
+func TestDriverRunMaxSteps(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{0x00, 0x00, 0x00, 0x00})
+	d := NewDriver(fdp)
+
+	d.Register("a", func(*FuzzedDataProvider) error { return nil })
+	d.Register("b", func(*FuzzedDataProvider) error { return nil })
+	d.SetMaxSteps(3)
+
+	require.NoError(t, d.Run())
+	assert.Equal(t, []string{"a", "a", "a"}, d.Trace())
+	assert.Equal(t, 1, fdp.RemainingBytes())
+}
+
+func TestDriverRunNoProgress(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{0x00})
+	d := NewDriver(fdp)
+
+	d.Register("nop", func(*FuzzedDataProvider) error { return nil })
+
+	require.NoError(t, d.Run())
+	assert.Equal(t, []string{"nop"}, d.Trace())
+
+	assert.Panics(t, func() {
+		_ = NewDriver(fdp).Run()
+	})
+}
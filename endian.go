@@ -0,0 +1,77 @@
+package fuzz
+
+import "encoding/binary"
+
+// consumeFixed fills b with the first len(b) bytes of input data.  If
+// fewer than len(b) bytes remain, the rest of b is left untouched.
+func (fdp *FuzzedDataProvider) consumeFixed(b []byte) {
+	fdp.advance(copy(b, fdp.data))
+}
+
+// ConsumeUint16BE returns uint16 decoded from the first 2 bytes of
+// input data in big-endian byte order.  Unlike ConsumeUint16, it
+// consumes bytes from the front of the input data, so that the input
+// looks like the wire format.  If fewer than 2 bytes remain, the
+// missing low-order bytes are treated as zeros.
+func (fdp *FuzzedDataProvider) ConsumeUint16BE() uint16 {
+	var b [2]byte
+
+	fdp.consumeFixed(b[:])
+
+	return binary.BigEndian.Uint16(b[:])
+}
+
+// ConsumeUint16LE returns uint16 decoded from the first 2 bytes of
+// input data in little-endian byte order.  If fewer than 2 bytes
+// remain, the missing high-order bytes are treated as zeros.
+func (fdp *FuzzedDataProvider) ConsumeUint16LE() uint16 {
+	var b [2]byte
+
+	fdp.consumeFixed(b[:])
+
+	return binary.LittleEndian.Uint16(b[:])
+}
+
+// ConsumeUint32BE returns uint32 decoded from the first 4 bytes of
+// input data in big-endian byte order.  If fewer than 4 bytes remain,
+// the missing low-order bytes are treated as zeros.
+func (fdp *FuzzedDataProvider) ConsumeUint32BE() uint32 {
+	var b [4]byte
+
+	fdp.consumeFixed(b[:])
+
+	return binary.BigEndian.Uint32(b[:])
+}
+
+// ConsumeUint32LE returns uint32 decoded from the first 4 bytes of
+// input data in little-endian byte order.  If fewer than 4 bytes
+// remain, the missing high-order bytes are treated as zeros.
+func (fdp *FuzzedDataProvider) ConsumeUint32LE() uint32 {
+	var b [4]byte
+
+	fdp.consumeFixed(b[:])
+
+	return binary.LittleEndian.Uint32(b[:])
+}
+
+// ConsumeUint64BE returns uint64 decoded from the first 8 bytes of
+// input data in big-endian byte order.  If fewer than 8 bytes remain,
+// the missing low-order bytes are treated as zeros.
+func (fdp *FuzzedDataProvider) ConsumeUint64BE() uint64 {
+	var b [8]byte
+
+	fdp.consumeFixed(b[:])
+
+	return binary.BigEndian.Uint64(b[:])
+}
+
+// ConsumeUint64LE returns uint64 decoded from the first 8 bytes of
+// input data in little-endian byte order.  If fewer than 8 bytes
+// remain, the missing high-order bytes are treated as zeros.
+func (fdp *FuzzedDataProvider) ConsumeUint64LE() uint64 {
+	var b [8]byte
+
+	fdp.consumeFixed(b[:])
+
+	return binary.LittleEndian.Uint64(b[:])
+}
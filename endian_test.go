@@ -0,0 +1,64 @@
+package fuzz
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsumeUint16BE(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{0x01, 0x02, 0x03})
+
+	assert.Equal(t, uint16(0x0102), fdp.ConsumeUint16BE())
+	assert.Equal(t, uint16(0x0300), fdp.ConsumeUint16BE())
+	assert.Equal(t, uint16(0), fdp.ConsumeUint16BE())
+}
+
+func TestConsumeUint16LE(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{0x01, 0x02, 0x03})
+
+	assert.Equal(t, uint16(0x0201), fdp.ConsumeUint16LE())
+	assert.Equal(t, uint16(0x0003), fdp.ConsumeUint16LE())
+	assert.Equal(t, uint16(0), fdp.ConsumeUint16LE())
+}
+
+func TestConsumeUint32BE(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{0x01, 0x02, 0x03, 0x04, 0x05})
+
+	assert.Equal(t, uint32(0x01020304), fdp.ConsumeUint32BE())
+	assert.Equal(t, uint32(0x05000000), fdp.ConsumeUint32BE())
+	assert.Equal(t, 0, fdp.RemainingBytes())
+}
+
+func TestConsumeUint32LE(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{0x01, 0x02, 0x03, 0x04, 0x05})
+
+	assert.Equal(t, uint32(0x04030201), fdp.ConsumeUint32LE())
+	assert.Equal(t, uint32(0x00000005), fdp.ConsumeUint32LE())
+	assert.Equal(t, 0, fdp.RemainingBytes())
+}
+
+func TestConsumeUint64BE(t *testing.T) {
+	fdp := NewFuzzedDataProvider(
+		[]byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09})
+
+	assert.Equal(t, uint64(0x0102030405060708), fdp.ConsumeUint64BE())
+	assert.Equal(t, uint64(0x0900000000000000), fdp.ConsumeUint64BE())
+}
+
+func TestConsumeUint64LE(t *testing.T) {
+	fdp := NewFuzzedDataProvider(
+		[]byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09})
+
+	assert.Equal(t, uint64(0x0807060504030201), fdp.ConsumeUint64LE())
+	assert.Equal(t, uint64(0x0000000000000009), fdp.ConsumeUint64LE())
+}
+
+func TestConsumeEndianMixed(t *testing.T) {
+	// Front and back consumption share the same data.
+	fdp := NewFuzzedDataProvider([]byte{0x12, 0x34, 0x56, 0x78})
+
+	assert.Equal(t, uint8(0x78), fdp.ConsumeUint8())
+	assert.Equal(t, uint16(0x1234), fdp.ConsumeUint16BE())
+	assert.Equal(t, uint16(0x5600), fdp.ConsumeUint16BE())
+}
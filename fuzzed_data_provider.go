@@ -9,6 +9,7 @@ import (
 
 type FuzzedDataProvider struct {
 	data []byte
+	dict []string
 }
 
 // NewFuzzedDataProvider returns new FuzzedDataProvider with data.
@@ -31,8 +32,17 @@ func (fdp *FuzzedDataProvider) advance(n int) {
 // ConsumeBytes returns slice containing the first n bytes of input
 // data.  If fewer than n data remain, it returns a shorter slice
 // containing all of the data that are left.  It returns a copy of
-// input data.
+// input data.  If a dictionary is attached, tokens are spliced between
+// chunks of input data as ConsumeDictionaryString does.
 func (fdp *FuzzedDataProvider) ConsumeBytes(n int) []byte {
+	if len(fdp.dict) != 0 {
+		return fdp.consumeDictionaryBytes(n)
+	}
+
+	return fdp.consumeBytes(n)
+}
+
+func (fdp *FuzzedDataProvider) consumeBytes(n int) []byte {
 	n = min(n, len(fdp.data))
 	if n == 0 {
 		return nil
@@ -45,15 +55,21 @@ func (fdp *FuzzedDataProvider) ConsumeBytes(n int) []byte {
 }
 
 // ConsumeRemainingBytes returns slice containing all remaining bytes
-// of the input data.  It returns a copy of input data.
+// of the input data.  It returns a copy of input data.  The dictionary
+// is not used.
 func (fdp *FuzzedDataProvider) ConsumeRemainingBytes() []byte {
-	return fdp.ConsumeBytes(len(fdp.data))
+	return fdp.consumeBytes(len(fdp.data))
 }
 
 // ConsumeBytesAsString returns string containing n bytes of input
 // data.  If fewer than n bytes of data remain, it returns a shorter
-// string containing all of the data that are left.
+// string containing all of the data that are left.  If a dictionary is
+// attached, tokens are spliced in the same way as ConsumeBytes.
 func (fdp *FuzzedDataProvider) ConsumeBytesAsString(n int) string {
+	if len(fdp.dict) != 0 {
+		return string(fdp.consumeDictionaryBytes(n))
+	}
+
 	n = min(n, len(fdp.data))
 	if n == 0 {
 		return ""
@@ -69,8 +85,19 @@ func (fdp *FuzzedDataProvider) ConsumeBytesAsString(n int) string {
 // maxLength.  When it runs out of input data, it returns what remains
 // of the input.  Designed to be more stable with respect to a fuzzer
 // inserting characters than just picking a random length and then
-// consuming that many bytes.
+// consuming that many bytes.  If a dictionary is attached, it is
+// equivalent to ConsumeDictionaryString.
 func (fdp *FuzzedDataProvider) ConsumeRandomLengthString(maxLength int) string {
+	if len(fdp.dict) != 0 {
+		return fdp.ConsumeDictionaryString(maxLength)
+	}
+
+	return fdp.consumeRandomLengthString(maxLength)
+}
+
+func (fdp *FuzzedDataProvider) consumeRandomLengthString(
+	maxLength int,
+) string {
 	var result strings.Builder
 
 	for i := 0; i < maxLength && len(fdp.data) != 0; i++ {
@@ -2,7 +2,10 @@ module github.com/ngtcp2/fuzzeddataprovider-go
 
 go 1.25
 
-require github.com/stretchr/testify v1.11.1
+require (
+	github.com/stretchr/testify v1.11.1
+	google.golang.org/protobuf v1.36.9
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
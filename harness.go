@@ -0,0 +1,96 @@
+package fuzz
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Harness applies the same operations to a system under test and a
+// reference model, and compares their observable results after each
+// step.  The operations are picked by Driver.
+type Harness[S, M any] struct {
+	driver *Driver
+	sut    S
+	model  M
+	equal  func(got, want any) bool
+}
+
+// NewHarness returns new Harness which consumes fdp to drive sut and
+// model.
+func NewHarness[S, M any](
+	fdp *FuzzedDataProvider, sut S, model M,
+) *Harness[S, M] {
+	return &Harness[S, M]{
+		driver: NewDriver(fdp),
+		sut:    sut,
+		model:  model,
+		equal:  reflect.DeepEqual,
+	}
+}
+
+// SetEqual sets the function which compares the results of the system
+// under test and the model.  The default is reflect.DeepEqual.
+func (h *Harness[S, M]) SetEqual(equal func(got, want any) bool) {
+	h.equal = equal
+}
+
+// Register registers op as an operation named name with weight 1.
+func (h *Harness[S, M]) Register(
+	name string, op func(fdp *FuzzedDataProvider, sut S, model M) (got,
+		want any),
+) {
+	h.RegisterWeighted(name, 1, op)
+}
+
+// RegisterWeighted registers op as an operation named name with
+// weight.  op should consume its arguments from fdp once, apply them
+// to both sut and model, and return the observable results of sut and
+// model as got and want respectively.
+func (h *Harness[S, M]) RegisterWeighted(
+	name string, weight uint,
+	op func(fdp *FuzzedDataProvider, sut S, model M) (got, want any),
+) {
+	h.driver.RegisterWeighted(name, weight,
+		func(fdp *FuzzedDataProvider) error {
+			got, want := op(fdp, h.sut, h.model)
+			if !h.equal(got, want) {
+				return &DivergenceError{
+					Got:  got,
+					Want: want,
+				}
+			}
+
+			return nil
+		})
+}
+
+// SetMaxSteps sets the maximum number of operations Run executes.  See
+// Driver.SetMaxSteps.
+func (h *Harness[S, M]) SetMaxSteps(n int) {
+	h.driver.SetMaxSteps(n)
+}
+
+// Run executes operations in the same way as Driver.Run.  If the
+// results of the system under test and the model diverge, it returns
+// *StepError which wraps *DivergenceError.
+func (h *Harness[S, M]) Run() error {
+	return h.driver.Run()
+}
+
+// Trace returns the names of the operations executed so far in order.
+func (h *Harness[S, M]) Trace() []string {
+	return h.driver.Trace()
+}
+
+// DivergenceError is the error that the results of the system under
+// test and the model differ.
+type DivergenceError struct {
+	// Got is the result of the system under test.
+	Got any
+	// Want is the result of the model.
+	Want any
+}
+
+func (e *DivergenceError) Error() string {
+	return fmt.Sprintf("diverged: got %v, want %v", e.Got, e.Want)
+}
@@ -0,0 +1,88 @@
+package fuzz
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testStack struct {
+	s []uint8
+}
+
+func (s *testStack) push(v uint8) {
+	if len(s.s) == 2 {
+		// Deliberately drop the bottom element.
+		s.s = s.s[1:]
+	}
+
+	s.s = append(s.s, v)
+}
+
+func (s *testStack) len() int {
+	return len(s.s)
+}
+
+func newTestHarness(fdp *FuzzedDataProvider) *Harness[*testStack, *[]uint8] {
+	h := NewHarness(fdp, &testStack{}, &[]uint8{})
+
+	h.Register("push", func(
+		fdp *FuzzedDataProvider, sut *testStack, model *[]uint8,
+	) (any, any) {
+		v := fdp.ConsumeUint8()
+
+		sut.push(v)
+		*model = append(*model, v)
+
+		return nil, nil
+	})
+	h.Register("len", func(
+		_ *FuzzedDataProvider, sut *testStack, model *[]uint8,
+	) (any, any) {
+		return sut.len(), len(*model)
+	})
+
+	return h
+}
+
+func TestHarnessRun(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{0x01, 0xbb, 0x00, 0xaa, 0x00})
+	h := newTestHarness(fdp)
+
+	require.NoError(t, h.Run())
+	assert.Equal(t, []string{"push", "push", "len"}, h.Trace())
+
+	fdp = NewFuzzedDataProvider(
+		[]byte{0x01, 0xcc, 0x00, 0xbb, 0x00, 0xaa, 0x00})
+	h = newTestHarness(fdp)
+	h.SetEqual(func(got, want any) bool {
+		n, ok := got.(int)
+
+		return !ok || n <= want.(int)
+	})
+
+	require.NoError(t, h.Run())
+}
+
+func TestHarnessRunDivergence(t *testing.T) {
+	fdp := NewFuzzedDataProvider(
+		[]byte{0x01, 0xcc, 0x00, 0xbb, 0x00, 0xaa, 0x00})
+	h := newTestHarness(fdp)
+
+	err := h.Run()
+
+	var (
+		stepErr *StepError
+		divErr  *DivergenceError
+	)
+
+	require.ErrorAs(t, err, &stepErr)
+	require.ErrorAs(t, err, &divErr)
+	assert.Equal(t, 3, stepErr.Step)
+	assert.Equal(t, []string{"push", "push", "push", "len"},
+		stepErr.Trace)
+	assert.Equal(t, 2, divErr.Got)
+	assert.Equal(t, 3, divErr.Want)
+	assert.Equal(t, "diverged: got 2, want 3", divErr.Error())
+}
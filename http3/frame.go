@@ -0,0 +1,250 @@
+// Package http3 provides helpers which generate HTTP/3 frames and QPACK
+// field sections and instructions from FuzzedDataProvider.
+package http3
+
+import (
+	fuzz "github.com/ngtcp2/fuzzeddataprovider-go"
+	"github.com/ngtcp2/fuzzeddataprovider-go/internal/gen"
+	"github.com/ngtcp2/fuzzeddataprovider-go/quic"
+)
+
+// Frame types defined in RFC 9114.
+const (
+	FrameTypeData        = 0x00
+	FrameTypeHeaders     = 0x01
+	FrameTypeCancelPush  = 0x03
+	FrameTypeSettings    = 0x04
+	FrameTypePushPromise = 0x05
+	FrameTypeGoaway      = 0x07
+	FrameTypeMaxPushID   = 0x0d
+	// FrameTypeReserved is the smallest reserved frame type of the
+	// form 0x1f * N + 0x21.  In Options.Types, it stands for all
+	// reserved frame types.
+	FrameTypeReserved = 0x21
+)
+
+// Settings identifiers defined in RFC 9114, RFC 9204, RFC 9220 and RFC
+// 9297.
+const (
+	SettingsQPACKMaxTableCapacity = 0x01
+	SettingsMaxFieldSectionSize   = 0x06
+	SettingsQPACKBlockedStreams   = 0x07
+	SettingsEnableConnectProtocol = 0x08
+	SettingsH3Datagram            = 0x33
+	// SettingsReserved is the smallest reserved settings identifier
+	// of the form 0x1f * N + 0x21.
+	SettingsReserved = 0x21
+)
+
+var frameTypes = []uint64{
+	FrameTypeData,
+	FrameTypeHeaders,
+	FrameTypeCancelPush,
+	FrameTypeSettings,
+	FrameTypePushPromise,
+	FrameTypeGoaway,
+	FrameTypeMaxPushID,
+	FrameTypeReserved,
+}
+
+// http2FrameTypes are the frame types defined in HTTP/2 but reserved
+// in HTTP/3.  Receiving them is a connection error.
+var http2FrameTypes = []uint64{0x02, 0x06, 0x08, 0x09}
+
+var settingsIDs = []uint64{
+	SettingsQPACKMaxTableCapacity,
+	SettingsMaxFieldSectionSize,
+	SettingsQPACKBlockedStreams,
+	SettingsEnableConnectProtocol,
+	SettingsH3Datagram,
+	SettingsReserved,
+}
+
+// http2SettingsIDs are the settings identifiers defined in HTTP/2 but
+// reserved in HTTP/3.
+var http2SettingsIDs = []uint64{0x02, 0x03, 0x04, 0x05}
+
+const (
+	// maxSettings is the maximum number of settings in SETTINGS frame.
+	maxSettings = 16
+	// maxReservedN is the maximum N of reserved values of the form
+	// 0x1f * N + 0x21.
+	maxReservedN = (quic.MaxVarint - 0x21) / 0x1f
+)
+
+// Options controls the frames, field sections and instructions
+// generated by this package.  The zero value generates any frame type
+// defined in RFC 9114 and field sections which refer only to the
+// static table with default limits.
+type Options struct {
+	// Types is the frame types to generate.  If Types is empty, all
+	// frame types are generated.
+	Types []uint64
+	// MaxFrames is the maximum number of frames ConsumeFrames
+	// generates.  If it is 0, 16 is used.
+	MaxFrames int
+	// MaxDataLen is the maximum length of variable length data, such
+	// as DATA frame payload and string literals.  If it is 0, 256 is
+	// used.
+	MaxDataLen int
+	// MaxFieldLines is the maximum number of field lines in a field
+	// section, and the maximum number of QPACK instructions.  If it is
+	// 0, 16 is used.
+	MaxFieldLines int
+	// MaxTableCapacity is the QPACK dynamic table capacity the decoder
+	// advertises.  If it is 0, field sections do not refer to the
+	// dynamic table.
+	MaxTableCapacity uint64
+	// AllowIllegal allows output which violates RFC 9114 and RFC 9204,
+	// such as HTTP/2 frame types and settings, length fields which do
+	// not match payload, duplicate settings, out of range indices and
+	// invalid Huffman padding.
+	AllowIllegal bool
+}
+
+type frameGen struct {
+	*qpackGen
+	types []uint64
+	// illegal is true if the frame being generated may violate RFC
+	// 9114.
+	illegal bool
+}
+
+func newFrameGen(fdp *fuzz.FuzzedDataProvider, opts *Options) *frameGen {
+	g := &frameGen{
+		qpackGen: newQPACKGen(fdp, opts),
+		types:    frameTypes,
+	}
+
+	if len(g.opts.Types) != 0 {
+		g.types = g.opts.Types
+	}
+
+	return g
+}
+
+// ConsumeReserved returns a reserved frame type or settings identifier
+// of the form 0x1f * N + 0x21 by consuming bytes from the input data.
+func ConsumeReserved(fdp *fuzz.FuzzedDataProvider) uint64 {
+	return 0x1f*fdp.ConsumeUint64InRange(0, maxReservedN) + 0x21
+}
+
+// ConsumeFrame returns an encoded HTTP/3 frame generated by consuming
+// bytes from the input data.  opts may be nil.  If there is no input
+// data left, it returns an empty DATA frame unless opts.Types says
+// otherwise.
+func ConsumeFrame(fdp *fuzz.FuzzedDataProvider, opts *Options) []byte {
+	return newFrameGen(fdp, opts).appendFrame(nil)
+}
+
+// ConsumeFrames returns a sequence of encoded HTTP/3 frames generated
+// by consuming bytes from the input data.  The number of frames is
+// chosen in the same way as fuzz.ConsumeSlice.  opts may be nil.
+func ConsumeFrames(fdp *fuzz.FuzzedDataProvider, opts *Options) []byte {
+	g := newFrameGen(fdp, opts)
+
+	var b []byte
+
+	for range gen.Limit(g.opts.MaxFrames, gen.DefaultMaxCount) {
+		if fdp.ConsumeUint8() == 0 {
+			break
+		}
+
+		b = g.appendFrame(b)
+	}
+
+	return b
+}
+
+func (g *frameGen) appendFrame(b []byte) []byte {
+	fdp := g.fdp
+
+	g.illegal = gen.ConsumeIllegal(fdp, g.opts.AllowIllegal)
+
+	typ := g.types[fdp.ConsumeIntInRange(0, len(g.types)-1)]
+	if typ == FrameTypeReserved {
+		typ = ConsumeReserved(fdp)
+	}
+
+	if g.illegal && fdp.ConsumeBool() {
+		if fdp.ConsumeBool() {
+			typ = http2FrameTypes[fdp.ConsumeIntInRange(0,
+				len(http2FrameTypes)-1)]
+		} else {
+			typ = quic.ConsumeVarint(fdp)
+		}
+	}
+
+	b = quic.AppendVarint(b, typ)
+
+	var payload []byte
+
+	switch typ {
+	case FrameTypeHeaders:
+		payload = g.appendFieldSection(nil)
+	case FrameTypeCancelPush, FrameTypeGoaway, FrameTypeMaxPushID:
+		payload = quic.AppendConsumedVarint(nil, fdp, quic.ConsumeVarint(fdp))
+	case FrameTypeSettings:
+		payload = g.consumeSettings()
+	case FrameTypePushPromise:
+		payload = quic.AppendConsumedVarint(nil, fdp, quic.ConsumeVarint(fdp))
+		payload = g.appendFieldSection(payload)
+	default:
+		// DATA, reserved and unknown frame types.
+		payload = fdp.ConsumeBytes(fdp.ConsumeIntInRange(0, g.maxStringLen))
+	}
+
+	return gen.AppendLengthPrefixed(fdp, g.illegal, b, payload,
+		quic.MaxVarint, func(b []byte, n uint64) []byte {
+			return quic.AppendConsumedVarint(b, fdp, n)
+		})
+}
+
+// consumeSettings returns the payload of SETTINGS frame.
+func (g *frameGen) consumeSettings() []byte {
+	fdp := g.fdp
+	seen := make(map[uint64]bool)
+
+	var b []byte
+
+	for range maxSettings {
+		if fdp.ConsumeUint8() == 0 {
+			break
+		}
+
+		id := settingsIDs[fdp.ConsumeIntInRange(0, len(settingsIDs)-1)]
+		if id == SettingsReserved {
+			id = ConsumeReserved(fdp)
+		}
+
+		if g.illegal && fdp.ConsumeBool() {
+			id = http2SettingsIDs[fdp.ConsumeIntInRange(0,
+				len(http2SettingsIDs)-1)]
+		}
+
+		if seen[id] && !g.illegal {
+			continue
+		}
+
+		seen[id] = true
+
+		var v uint64
+
+		switch {
+		case g.illegal:
+			v = quic.ConsumeVarint(fdp)
+		case id == SettingsEnableConnectProtocol ||
+			id == SettingsH3Datagram:
+			v = fdp.ConsumeUint64InRange(0, 1)
+		case id == SettingsQPACKMaxTableCapacity:
+			v = fdp.ConsumeUint64InRange(0, g.opts.MaxTableCapacity)
+		default:
+			v = quic.ConsumeVarint(fdp)
+		}
+
+		b = quic.AppendConsumedVarint(b, fdp, id)
+		b = quic.AppendConsumedVarint(b, fdp, v)
+	}
+
+	return b
+}
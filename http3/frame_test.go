@@ -0,0 +1,117 @@
+package http3
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	fuzz "github.com/ngtcp2/fuzzeddataprovider-go"
+	"github.com/ngtcp2/fuzzeddataprovider-go/internal/gentest"
+)
+
+// frames validates the frames in r according to RFC 9114.
+func (r *reader) frames(maxEntries uint64) {
+	for len(r.B) != 0 {
+		typ := r.varint()
+		payload := newReader(r.Bytes(r.varint()))
+
+		r.Check(!slices.Contains(http2FrameTypes, typ))
+
+		switch typ {
+		case FrameTypeHeaders:
+			payload.fieldSection(maxEntries)
+		case FrameTypeCancelPush, FrameTypeGoaway, FrameTypeMaxPushID:
+			payload.varint()
+		case FrameTypeSettings:
+			payload.settings()
+		case FrameTypePushPromise:
+			payload.varint()
+			payload.fieldSection(maxEntries)
+		default:
+			payload.B = nil
+		}
+
+		r.Check(len(payload.B) == 0)
+	}
+}
+
+func (r *reader) settings() {
+	seen := make(map[uint64]bool)
+
+	for len(r.B) != 0 {
+		id := r.varint()
+		v := r.varint()
+
+		r.Check(!seen[id] && !slices.Contains(http2SettingsIDs, id))
+
+		seen[id] = true
+
+		if id == SettingsEnableConnectProtocol || id == SettingsH3Datagram {
+			r.Check(v <= 1)
+		}
+	}
+}
+
+func TestConsumeReserved(t *testing.T) {
+	fdp := fuzz.NewFuzzedDataProvider([]byte{0x01})
+
+	assert.Equal(t, uint64(0x40), ConsumeReserved(fdp))
+	assert.Equal(t, uint64(0x21), ConsumeReserved(fdp))
+}
+
+func TestConsumeFrame(t *testing.T) {
+	fdp := fuzz.NewFuzzedDataProvider(nil)
+
+	assert.Equal(t, []byte{FrameTypeData, 0x00}, ConsumeFrame(fdp, nil))
+	assert.Equal(t, []byte{FrameTypeHeaders, 0x02, 0x00, 0x00},
+		ConsumeFrame(fdp, &Options{
+			Types: []uint64{FrameTypeHeaders},
+		}))
+
+	fdp = fuzz.NewFuzzedDataProvider([]byte{'f', 'o', 'o', 0x00, 0x03})
+
+	assert.Equal(t, []byte{FrameTypeData, 0x03, 'f', 'o', 'o'},
+		ConsumeFrame(fdp, &Options{
+			Types:      []uint64{FrameTypeData},
+			MaxDataLen: 3,
+		}))
+
+	fdp = fuzz.NewFuzzedDataProvider([]byte{0x01, 0x03, 0x01})
+
+	assert.Equal(t, []byte{FrameTypeSettings, 0x02, 0x08, 0x01},
+		ConsumeFrame(fdp, &Options{
+			Types: []uint64{FrameTypeSettings},
+		}))
+}
+
+func TestConsumeFrames(t *testing.T) {
+	fdp := fuzz.NewFuzzedDataProvider(
+		[]byte{0x00, 0x00, 0x01, 0x00, 0x00, 0x01})
+
+	assert.Equal(t, []byte{FrameTypeData, 0x00, FrameTypeData, 0x00},
+		ConsumeFrames(fdp, &Options{
+			Types:      []uint64{FrameTypeData},
+			MaxDataLen: 1,
+		}))
+
+	fdp = fuzz.NewFuzzedDataProvider(
+		[]byte{0x01, 0x00, 0x00, 0x01, 0x00, 0x00, 0x01})
+
+	assert.Equal(t, []byte{FrameTypeData, 0x00, FrameTypeData, 0x00},
+		ConsumeFrames(fdp, &Options{
+			Types:      []uint64{FrameTypeData},
+			MaxFrames:  2,
+			MaxDataLen: 1,
+		}))
+}
+
+func TestConsumeFramesProperty(t *testing.T) {
+	gentest.Check(t, func(data []byte, illegal bool) []byte {
+		return ConsumeFrames(fuzz.NewFuzzedDataProvider(data), &Options{
+			AllowIllegal: illegal,
+		})
+	}, func(b []byte) error {
+		return parse(func(r *reader) { r.frames(0) }, b)
+	})
+}
@@ -0,0 +1,20 @@
+package http3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendHuffman(t *testing.T) {
+	// Examples from RFC 7541 Appendix C.4.
+	assert.Equal(t, []byte{
+		0xf1, 0xe3, 0xc2, 0xe5, 0xf2, 0x3a, 0x6b, 0xa0, 0xab, 0x90, 0xf4, 0xff,
+	}, appendHuffman(nil, "www.example.com"))
+	assert.Equal(t, []byte{0xa8, 0xeb, 0x10, 0x64, 0x9c, 0xbf},
+		appendHuffman(nil, "no-cache"))
+	assert.Equal(t, []byte{0x25, 0xa8, 0x49, 0xe9, 0x5b, 0xb8, 0xe8, 0xb4, 0xbf},
+		appendHuffman(nil, "custom-value"))
+	assert.Empty(t, appendHuffman(nil, ""))
+	assert.Equal(t, []byte{0x00, 0x07}, appendHuffman([]byte{0x00}, "0"))
+}
@@ -0,0 +1,434 @@
+package http3
+
+import (
+	fuzz "github.com/ngtcp2/fuzzeddataprovider-go"
+	"github.com/ngtcp2/fuzzeddataprovider-go/internal/gen"
+	"github.com/ngtcp2/fuzzeddataprovider-go/quic"
+)
+
+const (
+	// staticTableLen is the number of entries in QPACK static table.
+	staticTableLen = 99
+	// entryOverhead is the overhead of dynamic table entry in bytes.
+	entryOverhead = 32
+)
+
+// appendInt appends v encoded as QPACK prefixed integer with n-bit
+// prefix to b.  flags are the bits of the first byte above the prefix.
+func appendInt(b []byte, flags byte, n uint, v uint64) []byte {
+	maxPrefix := uint64(1)<<n - 1
+	if v < maxPrefix {
+		return append(b, flags|byte(v))
+	}
+
+	b = append(b, flags|byte(maxPrefix))
+	v -= maxPrefix
+
+	for ; v >= 0x80; v >>= 7 {
+		b = append(b, byte(v)|0x80)
+	}
+
+	return append(b, byte(v))
+}
+
+type qpackGen struct {
+	fdp           *fuzz.FuzzedDataProvider
+	opts          *Options
+	maxStringLen  int
+	maxFieldLines int
+}
+
+func newQPACKGen(fdp *fuzz.FuzzedDataProvider, opts *Options) *qpackGen {
+	opts = gen.OrZero(opts)
+
+	return &qpackGen{
+		fdp:           fdp,
+		opts:          opts,
+		maxStringLen:  gen.Limit(opts.MaxDataLen, gen.DefaultMaxDataLen),
+		maxFieldLines: gen.Limit(opts.MaxFieldLines, gen.DefaultMaxCount),
+	}
+}
+
+// illegal returns true if the next field may violate RFC 9204.
+func (g *qpackGen) illegal() bool {
+	return gen.ConsumeIllegal(g.fdp, g.opts.AllowIllegal)
+}
+
+// consumeString returns a string chosen by ConsumeDictionaryString, so
+// that tokens in the dictionary attached to the provider are used.
+func (g *qpackGen) consumeString() string {
+	return g.fdp.ConsumeDictionaryString(g.maxStringLen)
+}
+
+// appendString appends s as a string literal to b.  flags are the bits
+// of the first byte above the Huffman flag, and n is the length of the
+// prefix of the string length.
+func (g *qpackGen) appendString(
+	b []byte, flags byte, n uint, s string,
+) []byte {
+	fdp := g.fdp
+	h := byte(1) << n
+
+	if !fdp.ConsumeBool() {
+		b = appendInt(b, flags, n, uint64(len(s)))
+
+		return append(b, s...)
+	}
+
+	enc := appendHuffman(nil, s)
+
+	if g.illegal() && len(enc) != 0 {
+		// Invalid padding, which is not the prefix of EOS.
+		enc[len(enc)-1] &^= 1
+	}
+
+	b = appendInt(b, flags|h, n, uint64(len(enc)))
+
+	return append(b, enc...)
+}
+
+func (g *qpackGen) consumeStaticIndex() uint64 {
+	if g.illegal() {
+		return quic.ConsumeVarint(g.fdp)
+	}
+
+	return g.fdp.ConsumeUint64InRange(0, staticTableLen-1)
+}
+
+// consumeDynamicIndex returns an index which is less than n.
+func (g *qpackGen) consumeDynamicIndex(n uint64) uint64 {
+	if n == 0 || g.illegal() {
+		return quic.ConsumeVarint(g.fdp)
+	}
+
+	return g.fdp.ConsumeUint64InRange(0, n-1)
+}
+
+// consumeRelativeIndex returns a relative index which refers to an
+// entry below both base and ric.  If base is greater than ric, the
+// smallest indices refer to the entries which are not inserted yet.
+func (g *qpackGen) consumeRelativeIndex(ric, base uint64) uint64 {
+	lo := base - min(base, ric)
+
+	return lo + g.consumeDynamicIndex(base-lo)
+}
+
+const (
+	fieldLineIndexed = iota
+	fieldLineIndexedPostBase
+	fieldLineLiteralNameRef
+	fieldLineLiteralPostBaseNameRef
+	fieldLineLiteralName
+)
+
+// fieldLineWeights are the weights of fieldLineIndexed,
+// fieldLineIndexedPostBase, fieldLineLiteralNameRef,
+// fieldLineLiteralPostBaseNameRef and fieldLineLiteralName.
+var fieldLineWeights = []uint{4, 1, 4, 1, 4}
+
+// ConsumeFieldSection returns a QPACK-encoded field section, which is
+// the payload of HEADERS and PUSH_PROMISE frames, generated by
+// consuming bytes from the input data.  Without opts.MaxTableCapacity,
+// the field lines refer only to the static table.  Otherwise, they
+// also refer to the dynamic table, and Required Insert Count and Base
+// are chosen consistently, but the decoder might not have the
+// referenced entries.  opts may be nil.
+func ConsumeFieldSection(fdp *fuzz.FuzzedDataProvider, opts *Options) []byte {
+	return newQPACKGen(fdp, opts).appendFieldSection(nil)
+}
+
+func (g *qpackGen) appendFieldSection(b []byte) []byte {
+	fdp := g.fdp
+
+	var ric, base uint64
+
+	if maxEntries := g.opts.MaxTableCapacity / entryOverhead; maxEntries != 0 {
+		ric = fdp.ConsumeUint64InRange(0, 2*maxEntries)
+		if ric != 0 {
+			base = fdp.ConsumeUint64InRange(0, 2*ric)
+			b = appendInt(b, 0, 8, ric%(2*maxEntries)+1)
+		} else {
+			b = appendInt(b, 0, 8, 0)
+		}
+	} else {
+		b = appendInt(b, 0, 8, 0)
+	}
+
+	if base >= ric {
+		b = appendInt(b, 0, 7, base-ric)
+	} else {
+		b = appendInt(b, 0x80, 7, ric-base-1)
+	}
+
+	for range g.maxFieldLines {
+		if fdp.ConsumeUint8() == 0 {
+			break
+		}
+
+		b = g.appendFieldLine(b, ric, base)
+	}
+
+	return b
+}
+
+func (g *qpackGen) appendFieldLine(b []byte, ric, base uint64) []byte {
+	fdp := g.fdp
+
+	// postBase is the number of entries which can be referred to by
+	// post-base index.
+	postBase := ric - min(base, ric)
+
+	typ := fdp.ConsumeWeightedIndex(fieldLineWeights)
+	if postBase == 0 && !g.opts.AllowIllegal {
+		switch typ {
+		case fieldLineIndexedPostBase:
+			typ = fieldLineIndexed
+		case fieldLineLiteralPostBaseNameRef:
+			typ = fieldLineLiteralNameRef
+		}
+	}
+
+	// Without entries below Base, only static table can be referred
+	// to by relative index.
+	static := base == 0 || fdp.ConsumeBool()
+
+	// N bit
+	var never byte
+
+	if fdp.ConsumeBool() {
+		never = 0xff
+	}
+
+	switch typ {
+	case fieldLineIndexed:
+		if static {
+			return appendInt(b, 0xc0, 6, g.consumeStaticIndex())
+		}
+
+		return appendInt(b, 0x80, 6, g.consumeRelativeIndex(ric, base))
+	case fieldLineIndexedPostBase:
+		return appendInt(b, 0x10, 4, g.consumeDynamicIndex(postBase))
+	case fieldLineLiteralNameRef:
+		if static {
+			b = appendInt(b, 0x50|never&0x20, 4, g.consumeStaticIndex())
+		} else {
+			b = appendInt(b, 0x40|never&0x20, 4, g.consumeRelativeIndex(ric, base))
+		}
+	case fieldLineLiteralPostBaseNameRef:
+		b = appendInt(b, never&0x08, 3, g.consumeDynamicIndex(postBase))
+	default:
+		b = g.appendString(b, 0x20|never&0x10, 3, g.consumeString())
+	}
+
+	return g.appendString(b, 0, 7, g.consumeString())
+}
+
+const (
+	encoderInstSetCapacity = iota
+	encoderInstInsertNameRef
+	encoderInstInsertLiteralName
+	encoderInstDuplicate
+)
+
+// encoderInstWeights are the weights of encoderInstSetCapacity,
+// encoderInstInsertNameRef, encoderInstInsertLiteralName and
+// encoderInstDuplicate.
+var encoderInstWeights = []uint{1, 4, 4, 2}
+
+// dynamicEntry is an entry of QPACK dynamic table.
+type dynamicEntry struct {
+	// nameLen is the length of the field name.
+	nameLen uint64
+	// size is the size of the entry defined in RFC 9204 Section 3.2.1.
+	size uint64
+}
+
+func newDynamicEntry(nameLen, valueLen uint64) dynamicEntry {
+	return dynamicEntry{
+		nameLen: nameLen,
+		size:    nameLen + valueLen + entryOverhead,
+	}
+}
+
+// dynamicTable tracks QPACK dynamic table which encoder instructions
+// build.
+type dynamicTable struct {
+	capacity uint64
+	// size is the sum of the sizes of entries.
+	size uint64
+	// entries are the entries which have not been evicted, from the
+	// oldest.
+	entries []dynamicEntry
+}
+
+// len returns the number of entries which have not been evicted.
+func (t *dynamicTable) len() uint64 {
+	return uint64(len(t.entries))
+}
+
+// entry returns the entry referred to by relative index idx, which
+// must be less than t.len().
+func (t *dynamicTable) entry(idx uint64) dynamicEntry {
+	return t.entries[t.len()-1-idx]
+}
+
+// setCapacity sets the capacity, and evicts the oldest entries until
+// the entries fit in it.
+func (t *dynamicTable) setCapacity(capacity uint64) {
+	t.capacity = capacity
+
+	for t.size > t.capacity && len(t.entries) != 0 {
+		t.size -= t.entries[0].size
+		t.entries = t.entries[1:]
+	}
+}
+
+// insert inserts e, and evicts the oldest entries until the entries fit
+// in the capacity.
+func (t *dynamicTable) insert(e dynamicEntry) {
+	t.entries = append(t.entries, e)
+	t.size += e.size
+	t.setCapacity(t.capacity)
+}
+
+// ConsumeEncoderInstructions returns a sequence of QPACK encoder stream
+// instructions generated by consuming bytes from the input data.  The
+// number of instructions is chosen in the same way as
+// fuzz.ConsumeSlice.  The instructions track the dynamic table as the
+// decoder does: Set Dynamic Table Capacity precedes the first
+// insertion, the capacity is at most opts.MaxTableCapacity, inserted
+// entries fit in the capacity after evicting the oldest entries, and
+// Duplicate and name references refer only to the entries which have
+// not been evicted.  Unless opts.AllowIllegal is true, the instructions
+// which would violate them are replaced or dropped.  opts may be nil.
+func ConsumeEncoderInstructions(
+	fdp *fuzz.FuzzedDataProvider, opts *Options,
+) []byte {
+	g := newQPACKGen(fdp, opts)
+
+	var (
+		b []byte
+		t dynamicTable
+	)
+
+	for range g.maxFieldLines {
+		if fdp.ConsumeUint8() == 0 {
+			break
+		}
+
+		inst := fdp.ConsumeWeightedIndex(encoderInstWeights)
+		if !g.opts.AllowIllegal {
+			switch {
+			case t.capacity == 0:
+				inst = encoderInstSetCapacity
+			case inst == encoderInstDuplicate && t.len() == 0:
+				inst = encoderInstInsertLiteralName
+			}
+		}
+
+		if inst == encoderInstSetCapacity {
+			capacity := fdp.ConsumeUint64InRange(0, g.opts.MaxTableCapacity)
+			if g.illegal() {
+				capacity = quic.ConsumeVarint(fdp)
+			}
+
+			b = appendInt(b, 0x20, 5, capacity)
+			t.setCapacity(capacity)
+
+			continue
+		}
+
+		mark := len(b)
+
+		var e dynamicEntry
+
+		switch inst {
+		case encoderInstInsertNameRef:
+			var nameLen uint64
+
+			if t.len() == 0 || fdp.ConsumeBool() {
+				idx := g.consumeStaticIndex()
+				b = appendInt(b, 0xc0, 6, idx)
+
+				if idx < staticTableLen {
+					nameLen = uint64(len(staticTableNames[idx]))
+				}
+			} else {
+				idx := g.consumeDynamicIndex(t.len())
+				b = appendInt(b, 0x80, 6, idx)
+
+				if idx < t.len() {
+					nameLen = t.entry(idx).nameLen
+				}
+			}
+
+			value := g.consumeString()
+			b = g.appendString(b, 0, 7, value)
+			e = newDynamicEntry(nameLen, uint64(len(value)))
+		case encoderInstInsertLiteralName:
+			name := g.consumeString()
+			b = g.appendString(b, 0x40, 5, name)
+			value := g.consumeString()
+			b = g.appendString(b, 0, 7, value)
+			e = newDynamicEntry(uint64(len(name)), uint64(len(value)))
+		default:
+			idx := g.consumeDynamicIndex(t.len())
+			b = appendInt(b, 0, 5, idx)
+
+			if idx < t.len() {
+				e = t.entry(idx)
+			}
+		}
+
+		if e.size > t.capacity && !g.illegal() {
+			b = b[:mark]
+
+			continue
+		}
+
+		t.insert(e)
+	}
+
+	return b
+}
+
+const (
+	decoderInstSectionAck = iota
+	decoderInstStreamCancellation
+	decoderInstInsertCountIncrement
+)
+
+// ConsumeDecoderInstructions returns a sequence of QPACK decoder stream
+// instructions generated by consuming bytes from the input data.  The
+// number of instructions is chosen in the same way as
+// fuzz.ConsumeSlice.  opts may be nil.
+func ConsumeDecoderInstructions(
+	fdp *fuzz.FuzzedDataProvider, opts *Options,
+) []byte {
+	g := newQPACKGen(fdp, opts)
+
+	var b []byte
+
+	for range g.maxFieldLines {
+		if fdp.ConsumeUint8() == 0 {
+			break
+		}
+
+		switch fdp.ConsumeIntInRange(decoderInstSectionAck,
+			decoderInstInsertCountIncrement) {
+		case decoderInstSectionAck:
+			b = appendInt(b, 0x80, 7, quic.ConsumeVarint(fdp))
+		case decoderInstStreamCancellation:
+			b = appendInt(b, 0x40, 6, quic.ConsumeVarint(fdp))
+		default:
+			increment := quic.ConsumeVarint(fdp)
+			if increment == 0 && !g.illegal() {
+				increment = 1
+			}
+
+			b = appendInt(b, 0, 6, increment)
+		}
+	}
+
+	return b
+}
@@ -0,0 +1,291 @@
+package http3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	fuzz "github.com/ngtcp2/fuzzeddataprovider-go"
+	"github.com/ngtcp2/fuzzeddataprovider-go/internal/gentest"
+)
+
+type reader struct {
+	gentest.Reader
+}
+
+func newReader(b []byte) *reader {
+	return &reader{gentest.Reader{B: b}}
+}
+
+func (r *reader) varint() uint64 {
+	n := 1 << (r.Peek() >> 6)
+	v := r.Uint(n)
+
+	return v & (1<<(8*n-2) - 1)
+}
+
+// int decodes a QPACK prefixed integer with n-bit prefix.
+func (r *reader) int(n uint) uint64 {
+	maxPrefix := uint64(1)<<n - 1
+	v := uint64(r.Byte()) & maxPrefix
+
+	if v < maxPrefix {
+		return v
+	}
+
+	for shift := uint(0); ; shift += 7 {
+		r.Check(shift <= 56)
+
+		c := r.Byte()
+		v += uint64(c&0x7f) << shift
+
+		if c&0x80 == 0 {
+			return v
+		}
+	}
+}
+
+// string decodes a QPACK string literal whose length has n-bit prefix,
+// and returns the length of the decoded string.
+func (r *reader) string(n uint) uint64 {
+	h := r.Peek()&(1<<n) != 0
+	b := r.Bytes(r.int(n))
+
+	if !h {
+		return uint64(len(b))
+	}
+
+	l, ok := huffmanLen(b)
+	r.Check(ok)
+
+	return l
+}
+
+type huffmanCode struct {
+	code uint32
+	n    uint8
+}
+
+var huffmanSymbols = func() map[huffmanCode]bool {
+	m := make(map[huffmanCode]bool)
+
+	for i := range huffmanCodes {
+		m[huffmanCode{huffmanCodes[i], huffmanCodeLens[i]}] = true
+	}
+
+	return m
+}()
+
+// huffmanLen returns the number of symbols in Huffman-encoded string
+// b.  It returns false if b is not valid.
+func huffmanLen(b []byte) (uint64, bool) {
+	var (
+		c huffmanCode
+		l uint64
+	)
+
+	for _, x := range b {
+		for i := 7; i >= 0; i-- {
+			c.code = c.code<<1 | uint32(x>>i&1)
+			c.n++
+
+			if huffmanSymbols[c] {
+				c = huffmanCode{}
+				l++
+			} else if c.n >= 30 {
+				return 0, false
+			}
+		}
+	}
+
+	return l, c.n < 8 && c.code == 1<<c.n-1
+}
+
+// fieldSection validates a field section according to RFC 9204.
+// maxEntries is derived from the decoder's maximum table capacity.
+func (r *reader) fieldSection(maxEntries uint64) {
+	var ric uint64
+
+	if enc := r.int(8); enc != 0 {
+		r.Check(enc <= 2*maxEntries)
+
+		ric = enc - 1
+		if ric == 0 {
+			ric = 2 * maxEntries
+		}
+	}
+
+	sign := r.Peek()&0x80 != 0
+	delta := r.int(7)
+	base := ric + delta
+
+	if sign {
+		r.Check(ric > delta)
+
+		base = ric - delta - 1
+	}
+
+	for len(r.B) != 0 {
+		c := r.Peek()
+
+		switch {
+		case c&0x80 != 0:
+			r.index(c&0x40 != 0, r.int(6), ric, base)
+		case c&0x40 != 0:
+			r.index(c&0x10 != 0, r.int(4), ric, base)
+			r.string(7)
+		case c&0x20 != 0:
+			r.string(3)
+			r.string(7)
+		case c&0x10 != 0:
+			r.Check(r.int(4) < ric-base)
+		default:
+			r.Check(r.int(3) < ric-base)
+			r.string(7)
+		}
+	}
+}
+
+func (r *reader) index(static bool, idx, ric, base uint64) {
+	if static {
+		r.Check(idx < staticTableLen)
+	} else {
+		// The absolute index base-1-idx must be less than ric.
+		r.Check(idx < base && base-1-idx < ric)
+	}
+}
+
+// encoderInstructions validates encoder stream instructions according
+// to RFC 9204.  maxCapacity is the decoder's maximum table capacity.
+func (r *reader) encoderInstructions(maxCapacity uint64) {
+	var (
+		capacity, size uint64
+		// sizes and nameLens are the sizes and the name lengths of the
+		// entries which have not been evicted, from the oldest.
+		sizes, nameLens []uint64
+	)
+
+	evict := func() {
+		for size > capacity {
+			size -= sizes[0]
+			sizes, nameLens = sizes[1:], nameLens[1:]
+		}
+	}
+	insert := func(nameLen, entrySize uint64) {
+		r.Check(entrySize <= capacity)
+
+		sizes = append(sizes, entrySize)
+		nameLens = append(nameLens, nameLen)
+		size += entrySize
+		evict()
+	}
+	// live returns the absolute position in sizes of the entry referred
+	// to by relative index idx.
+	live := func(idx uint64) int {
+		r.Check(idx < uint64(len(sizes)))
+
+		return len(sizes) - 1 - int(idx)
+	}
+
+	for len(r.B) != 0 {
+		c := r.Peek()
+
+		switch {
+		case c&0x80 != 0:
+			var nameLen uint64
+
+			if idx := r.int(6); c&0x40 != 0 {
+				r.Check(idx < staticTableLen)
+
+				nameLen = uint64(len(staticTableNames[idx]))
+			} else {
+				nameLen = nameLens[live(idx)]
+			}
+
+			insert(nameLen, nameLen+r.string(7)+entryOverhead)
+		case c&0x40 != 0:
+			nameLen := r.string(5)
+			insert(nameLen, nameLen+r.string(7)+entryOverhead)
+		case c&0x20 != 0:
+			capacity = r.int(5)
+			r.Check(capacity <= maxCapacity)
+			evict()
+		default:
+			i := live(r.int(5))
+			insert(nameLens[i], sizes[i])
+		}
+	}
+}
+
+func parse(f func(r *reader), b []byte) error {
+	return gentest.Parse(func() { f(newReader(b)) })
+}
+
+func TestAppendInt(t *testing.T) {
+	// Examples from RFC 7541 Appendix C.1.
+	assert.Equal(t, []byte{0x0a}, appendInt(nil, 0, 5, 10))
+	assert.Equal(t, []byte{0x1f, 0x9a, 0x0a}, appendInt(nil, 0, 5, 1337))
+	assert.Equal(t, []byte{0x2a}, appendInt(nil, 0, 8, 42))
+	assert.Equal(t, []byte{0xff, 0x00}, appendInt(nil, 0xc0, 6, 63))
+}
+
+func TestConsumeFieldSection(t *testing.T) {
+	fdp := fuzz.NewFuzzedDataProvider(nil)
+
+	assert.Equal(t, []byte{0x00, 0x00}, ConsumeFieldSection(fdp, nil))
+
+	// :method GET is index 17 of static table.
+	fdp = fuzz.NewFuzzedDataProvider([]byte{0x11, 0x00, 0x00, 0x01})
+
+	assert.Equal(t, []byte{0x00, 0x00, 0xd1}, ConsumeFieldSection(fdp, nil))
+}
+
+func TestConsumeFieldSectionProperty(t *testing.T) {
+	const maxTableCapacity = 4096
+
+	gentest.Check(t, func(data []byte, illegal bool) []byte {
+		return ConsumeFieldSection(fuzz.NewFuzzedDataProvider(data), &Options{
+			MaxTableCapacity: maxTableCapacity,
+			AllowIllegal:     illegal,
+		})
+	}, func(b []byte) error {
+		return parse(func(r *reader) {
+			r.fieldSection(maxTableCapacity / entryOverhead)
+		}, b)
+	})
+}
+
+func TestConsumeEncoderInstructions(t *testing.T) {
+	fdp := fuzz.NewFuzzedDataProvider(nil)
+
+	assert.Empty(t, ConsumeEncoderInstructions(fdp, nil))
+
+	// Set Dynamic Table Capacity to 64.
+	fdp = fuzz.NewFuzzedDataProvider([]byte{0x40, 0x00, 0x01})
+
+	assert.Equal(t, []byte{0x3f, 0x21}, ConsumeEncoderInstructions(fdp,
+		&Options{MaxTableCapacity: 0x40}))
+}
+
+func TestConsumeEncoderInstructionsProperty(t *testing.T) {
+	const maxTableCapacity = 512
+
+	gentest.Check(t, func(data []byte, illegal bool) []byte {
+		return ConsumeEncoderInstructions(fuzz.NewFuzzedDataProvider(data),
+			&Options{
+				MaxTableCapacity: maxTableCapacity,
+				AllowIllegal:     illegal,
+			})
+	}, func(b []byte) error {
+		return parse(func(r *reader) {
+			r.encoderInstructions(maxTableCapacity)
+		}, b)
+	})
+}
+
+func TestConsumeDecoderInstructions(t *testing.T) {
+	// Insert Count Increment must not be 0.
+	fdp := fuzz.NewFuzzedDataProvider([]byte{0x00, 0x00, 0x02, 0x01})
+
+	assert.Equal(t, []byte{0x01}, ConsumeDecoderInstructions(fdp, nil))
+}
@@ -0,0 +1,105 @@
+package http3
+
+// staticTableNames are the field names of QPACK static table in RFC
+// 9204 Appendix A, indexed by absolute index.
+var staticTableNames = [staticTableLen]string{
+	":authority",
+	":path",
+	"age",
+	"content-disposition",
+	"content-length",
+	"cookie",
+	"date",
+	"etag",
+	"if-modified-since",
+	"if-none-match",
+	"last-modified",
+	"link",
+	"location",
+	"referer",
+	"set-cookie",
+	":method",
+	":method",
+	":method",
+	":method",
+	":method",
+	":method",
+	":method",
+	":scheme",
+	":scheme",
+	":status",
+	":status",
+	":status",
+	":status",
+	":status",
+	"accept",
+	"accept",
+	"accept-encoding",
+	"accept-ranges",
+	"access-control-allow-headers",
+	"access-control-allow-headers",
+	"access-control-allow-origin",
+	"cache-control",
+	"cache-control",
+	"cache-control",
+	"cache-control",
+	"cache-control",
+	"cache-control",
+	"content-encoding",
+	"content-encoding",
+	"content-type",
+	"content-type",
+	"content-type",
+	"content-type",
+	"content-type",
+	"content-type",
+	"content-type",
+	"content-type",
+	"content-type",
+	"content-type",
+	"content-type",
+	"range",
+	"strict-transport-security",
+	"strict-transport-security",
+	"strict-transport-security",
+	"vary",
+	"vary",
+	"x-content-type-options",
+	"x-xss-protection",
+	":status",
+	":status",
+	":status",
+	":status",
+	":status",
+	":status",
+	":status",
+	":status",
+	":status",
+	"accept-language",
+	"access-control-allow-credentials",
+	"access-control-allow-credentials",
+	"access-control-allow-headers",
+	"access-control-allow-methods",
+	"access-control-allow-methods",
+	"access-control-allow-methods",
+	"access-control-expose-headers",
+	"access-control-request-headers",
+	"access-control-request-method",
+	"access-control-request-method",
+	"alt-svc",
+	"authorization",
+	"content-security-policy",
+	"early-data",
+	"expect-ct",
+	"forwarded",
+	"if-range",
+	"origin",
+	"purpose",
+	"server",
+	"timing-allow-origin",
+	"upgrade-insecure-requests",
+	"user-agent",
+	"x-forwarded-for",
+	"x-frame-options",
+	"x-frame-options",
+}
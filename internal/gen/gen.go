@@ -0,0 +1,83 @@
+// Package gen provides the helpers shared by the generators in the
+// protocol subpackages.
+//
+// Each generator takes Options whose limits use a default when they
+// are 0, and whose AllowIllegal field allows malformed output.
+// Whether malformed output may be generated is decided by
+// ConsumeIllegal per item, such as a frame, a message field or a data
+// item, rather than once per call, so that well-formed items are still
+// generated around the malformed ones when AllowIllegal is true.
+package gen
+
+import fuzz "github.com/ngtcp2/fuzzeddataprovider-go"
+
+// Default limits used when the corresponding option is 0.
+const (
+	// DefaultMaxDepth is the default maximum nesting depth.
+	DefaultMaxDepth = 8
+	// DefaultMaxCount is the default maximum number of items in a
+	// sequence, such as frames, messages and elements.
+	DefaultMaxCount = 16
+	// DefaultMaxDataLen is the default maximum length of variable
+	// length data.
+	DefaultMaxDataLen = 256
+)
+
+// OrZero returns opts, or the zero value of T if opts is nil.
+func OrZero[T any](opts *T) *T {
+	if opts == nil {
+		return new(T)
+	}
+
+	return opts
+}
+
+// Limit returns v if it is not 0, and def otherwise.
+func Limit(v, def int) int {
+	if v == 0 {
+		return def
+	}
+
+	return v
+}
+
+// ConsumeIllegal returns true if the next item may be malformed.  If
+// allow is false, it returns false without consuming any data.
+func ConsumeIllegal(fdp *fuzz.FuzzedDataProvider, allow bool) bool {
+	return allow && fdp.ConsumeBool()
+}
+
+// ConsumeLength returns the length n of data or the number of items.
+// If illegal is true, it might return a value in the range [0,
+// min(2*n+1, maxLen)] chosen by consuming bytes from the input data
+// instead, so that the length might not match the content.
+func ConsumeLength(
+	fdp *fuzz.FuzzedDataProvider, illegal bool, n, maxLen uint64,
+) uint64 {
+	if illegal && fdp.ConsumeBool() {
+		return fdp.ConsumeUint64InRange(0, min(2*n+1, maxLen))
+	}
+
+	return n
+}
+
+// AppendLengthPrefixed appends data prefixed with its length to b.
+// appendLen appends the length to b.  The length is chosen by
+// ConsumeLength.
+func AppendLengthPrefixed(
+	fdp *fuzz.FuzzedDataProvider, illegal bool, b, data []byte,
+	maxLen uint64, appendLen func(b []byte, n uint64) []byte,
+) []byte {
+	b = appendLen(b, ConsumeLength(fdp, illegal, uint64(len(data)), maxLen))
+
+	return append(b, data...)
+}
+
+// AppendZeroPadded appends n bytes consumed from the input data to b.
+// If fewer than n bytes remain, the rest is filled with zeros.
+func AppendZeroPadded(b []byte, fdp *fuzz.FuzzedDataProvider, n int) []byte {
+	data := fdp.ConsumeBytes(n)
+	b = append(b, data...)
+
+	return append(b, make([]byte, n-len(data))...)
+}
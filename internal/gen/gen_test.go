@@ -0,0 +1,70 @@
+package gen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	fuzz "github.com/ngtcp2/fuzzeddataprovider-go"
+)
+
+type testOptions struct {
+	MaxDepth int
+}
+
+func TestOrZero(t *testing.T) {
+	assert.Equal(t, &testOptions{}, OrZero[testOptions](nil))
+
+	opts := &testOptions{MaxDepth: 1}
+
+	assert.Same(t, opts, OrZero(opts))
+}
+
+func TestLimit(t *testing.T) {
+	assert.Equal(t, DefaultMaxDepth, Limit(0, DefaultMaxDepth))
+	assert.Equal(t, 1, Limit(1, DefaultMaxDepth))
+}
+
+func TestConsumeIllegal(t *testing.T) {
+	fdp := fuzz.NewFuzzedDataProvider([]byte{0x01})
+
+	assert.False(t, ConsumeIllegal(fdp, false))
+	assert.Equal(t, 1, fdp.RemainingBytes())
+	assert.True(t, ConsumeIllegal(fdp, true))
+}
+
+func TestConsumeLength(t *testing.T) {
+	fdp := fuzz.NewFuzzedDataProvider([]byte{0x05, 0x01})
+
+	assert.Equal(t, uint64(3), ConsumeLength(fdp, false, 3, 255))
+	assert.Equal(t, 2, fdp.RemainingBytes())
+	assert.Equal(t, uint64(5), ConsumeLength(fdp, true, 3, 255))
+}
+
+func appendUint8(b []byte, n uint64) []byte {
+	return append(b, byte(n))
+}
+
+func TestAppendLengthPrefixed(t *testing.T) {
+	fdp := fuzz.NewFuzzedDataProvider([]byte{0x05, 0x01})
+
+	assert.Equal(t, []byte{0x03, 'f', 'o', 'o'},
+		AppendLengthPrefixed(fdp, false, nil, []byte("foo"), 255,
+			appendUint8))
+	assert.Equal(t, []byte{0x05, 'f', 'o', 'o'},
+		AppendLengthPrefixed(fdp, true, nil, []byte("foo"), 255,
+			appendUint8))
+
+	// The length is at most maxLen.
+	fdp = fuzz.NewFuzzedDataProvider([]byte{0xff, 0x01})
+
+	assert.Equal(t, []byte{0x01, 'f', 'o', 'o'},
+		AppendLengthPrefixed(fdp, true, nil, []byte("foo"), 1, appendUint8))
+}
+
+func TestAppendZeroPadded(t *testing.T) {
+	fdp := fuzz.NewFuzzedDataProvider([]byte{0xba, 0xad})
+
+	assert.Equal(t, []byte{0xff, 0xba, 0xad, 0x00},
+		AppendZeroPadded([]byte{0xff}, fdp, 3))
+}
@@ -0,0 +1,142 @@
+// Package gentest provides the helpers shared by the tests of the
+// generators in the protocol subpackages.
+package gentest
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// numInputs is the number of inputs Check generates for each of
+	// legal and illegal output.
+	numInputs = 1000
+	// inputLen is the length of the inputs Check generates.
+	inputLen = 1024
+	// minRejected is the minimum number of illegal outputs which parse
+	// must reject.
+	minRejected = numInputs / 5
+)
+
+// MalformedError is the type of the error which rejects a malformed
+// output.  It is distinct from the errors which buggy parsers and
+// generators panic with, such as runtime.Error.
+type MalformedError struct{}
+
+func (MalformedError) Error() string {
+	return "malformed input"
+}
+
+// ErrMalformed is the error which Reader panics with when the input is
+// malformed.
+var ErrMalformed = MalformedError{}
+
+// Reader reads the output of a generator.  Its methods panic with
+// ErrMalformed instead of returning an error, so that parsers built on
+// it do not check errors after each read.  Parse recovers the panic.
+type Reader struct {
+	B []byte
+}
+
+// Check panics with ErrMalformed if cond is false.
+func (r *Reader) Check(cond bool) {
+	if !cond {
+		panic(ErrMalformed)
+	}
+}
+
+// Peek returns the next byte without consuming it.
+func (r *Reader) Peek() byte {
+	r.Check(len(r.B) != 0)
+
+	return r.B[0]
+}
+
+// Byte consumes a byte.
+func (r *Reader) Byte() byte {
+	c := r.Peek()
+	r.B = r.B[1:]
+
+	return c
+}
+
+// Bytes consumes n bytes.
+func (r *Reader) Bytes(n uint64) []byte {
+	r.Check(uint64(len(r.B)) >= n)
+
+	b := r.B[:n]
+	r.B = r.B[n:]
+
+	return b
+}
+
+// Uint consumes n bytes as big-endian unsigned integer.
+func (r *Reader) Uint(n int) uint64 {
+	var v uint64
+
+	for _, c := range r.Bytes(uint64(n)) {
+		v = v<<8 | uint64(c)
+	}
+
+	return v
+}
+
+// Parse calls f, and returns ErrMalformed if f panics with it.  Any
+// other panic, such as index out of range, is a bug of the parser or
+// the generator, and is not recovered.
+func Parse(f func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(MalformedError); !ok {
+				panic(r)
+			}
+
+			err = ErrMalformed
+		}
+	}()
+
+	f()
+
+	return nil
+}
+
+// Check runs the property test of a generator over pseudo-random
+// inputs.  consume returns the output generated from data, with
+// AllowIllegal set if illegal is true.  parse must accept every
+// output generated without AllowIllegal, and reject at least a fifth
+// of the outputs generated with it.  The inputs are the same on every
+// run.
+func Check(
+	t *testing.T, consume func(data []byte, illegal bool) []byte,
+	parse func(b []byte) error,
+) {
+	t.Helper()
+
+	r := rand.New(rand.NewPCG(1, 2))
+	data := make([]byte, inputLen)
+
+	var nerrs int
+
+	for _, illegal := range []bool{false, true} {
+		for range numInputs {
+			for i := range data {
+				data[i] = byte(r.Uint32())
+			}
+
+			b := consume(data, illegal)
+
+			err := parse(b)
+			if !illegal {
+				require.NoError(t, err, "%x", b)
+			} else if err != nil {
+				nerrs++
+			}
+		}
+	}
+
+	assert.GreaterOrEqual(t, nerrs, minRejected,
+		"too few illegal outputs rejected")
+}
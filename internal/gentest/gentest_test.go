@@ -0,0 +1,32 @@
+package gentest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReader(t *testing.T) {
+	r := &Reader{B: []byte{0x01, 0x02, 0x03, 0x04}}
+
+	assert.Equal(t, byte(0x01), r.Peek())
+	assert.Equal(t, byte(0x01), r.Byte())
+	assert.Equal(t, uint64(0x0203), r.Uint(2))
+	assert.Equal(t, []byte{0x04}, r.Bytes(1))
+	require.ErrorIs(t, Parse(func() { r.Byte() }), ErrMalformed)
+}
+
+func TestParse(t *testing.T) {
+	require.NoError(t, Parse(func() {}))
+	require.ErrorIs(t, Parse(func() { panic(ErrMalformed) }), ErrMalformed)
+
+	// Bugs of parsers are not reported as malformed input.
+	assert.Panics(t, func() {
+		_ = Parse(func() {
+			var b []byte
+
+			_ = b[0]
+		})
+	})
+}
@@ -0,0 +1,410 @@
+package fuzz
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+const (
+	jsonNull = iota
+	jsonBool
+	jsonNumber
+	jsonString
+	jsonArray
+	jsonObject
+)
+
+const (
+	// maxHugeDigits is the maximum number of digits of a huge number.
+	maxHugeDigits = 400
+	// maxHugeExponent is the maximum absolute value of the exponent of
+	// a huge number.
+	maxHugeExponent = 99999
+)
+
+// invalidEscapes are the characters which must not follow a backslash
+// in JSON strings.
+const invalidEscapes = "0'aUvx \x00"
+
+// JSONOptions enables edge cases in the JSON text returned by
+// ConsumeJSON.  The zero value generates JSON text which every
+// conforming parser accepts.
+type JSONOptions struct {
+	// DuplicateKeys allows objects which have the same key more than
+	// once.  The returned value has the last one, as encoding/json
+	// does.
+	DuplicateKeys bool
+	// HugeNumbers allows numbers with up to 400 digits and exponents
+	// which do not fit in float64.  The returned value is the one
+	// strconv.ParseFloat returns, which might be infinity.
+	HugeNumbers bool
+	// InvalidEscapes allows backslashes followed by characters which do
+	// not form an escape sequence.  The resulting text is not valid
+	// JSON.  The returned string has the character without the
+	// backslash.
+	InvalidEscapes bool
+	// LoneSurrogates allows \u escapes of UTF-16 surrogates which are
+	// not part of a surrogate pair.  The resulting text is valid JSON,
+	// but not valid Unicode.  The returned string has U+FFFD in place
+	// of the escape, as encoding/json does.
+	LoneSurrogates bool
+}
+
+type jsonGen struct {
+	fdp      *FuzzedDataProvider
+	opts     *JSONOptions
+	maxDepth int
+}
+
+// ConsumeJSON returns a JSON value and its encoding generated by
+// consuming bytes from the input data.  The value is one of nil, bool,
+// float64, string, []any and map[string]any, as encoding/json decodes
+// into any.  Arrays and objects are nested at most maxDepth levels.
+// The encoding is at most maxSize bytes long, but a single digit is
+// returned if maxSize is less than 1.  Strings are built with
+// ConsumeDictionaryString, so that dictionary tokens appear in keys
+// and values.  opts may be nil.  If there is no input data left, it
+// returns nil and "null" if it fits in maxSize.
+func (fdp *FuzzedDataProvider) ConsumeJSON(
+	maxDepth, maxSize int, opts *JSONOptions,
+) (any, []byte) {
+	if opts == nil {
+		opts = &JSONOptions{}
+	}
+
+	g := &jsonGen{
+		fdp:      fdp,
+		opts:     opts,
+		maxDepth: maxDepth,
+	}
+
+	return g.appendValue(nil, 0, max(maxSize, 1))
+}
+
+// appendValue appends a JSON value to b.  The appended encoding is at
+// most limit bytes long.  limit must be positive.
+func (g *jsonGen) appendValue(b []byte, depth, limit int) (any, []byte) {
+	fdp := g.fdp
+
+	maxKind := jsonObject
+	if depth >= g.maxDepth {
+		maxKind = jsonString
+	}
+
+	switch fdp.ConsumeIntInRange(jsonNull, maxKind) {
+	case jsonNull:
+		if limit >= len("null") {
+			return nil, append(b, "null"...)
+		}
+	case jsonBool:
+		if fdp.ConsumeBool() {
+			if limit >= len("true") {
+				return true, append(b, "true"...)
+			}
+		} else if limit >= len("false") {
+			return false, append(b, "false"...)
+		}
+	case jsonNumber:
+		v, enc := g.consumeNumber()
+		if len(enc) <= limit {
+			return v, append(b, enc...)
+		}
+	case jsonString:
+		if limit >= len(`""`) {
+			return g.appendString(b, fdp.ConsumeDictionaryString(limit), limit)
+		}
+	case jsonArray:
+		if limit >= len("[]") {
+			return g.appendArray(b, depth, limit)
+		}
+	case jsonObject:
+		if limit >= len("{}") {
+			return g.appendObject(b, depth, limit)
+		}
+	}
+
+	// The chosen value does not fit in limit.
+	d := fdp.ConsumeIntInRange(0, 9)
+
+	return float64(d), strconv.AppendInt(b, int64(d), 10)
+}
+
+// consumeNumber returns a number and its encoding.
+func (g *jsonGen) consumeNumber() (float64, []byte) {
+	fdp := g.fdp
+
+	if g.opts.HugeNumbers && fdp.ConsumeBool() {
+		return g.consumeHugeNumber()
+	}
+
+	var v float64
+
+	if fdp.ConsumeBool() {
+		v = float64(fdp.ConsumeInt32())
+	} else {
+		v = fdp.ConsumeFloat64()
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			v = 0
+		}
+	}
+
+	return v, strconv.AppendFloat(nil, v, 'g', -1, 64)
+}
+
+// consumeHugeNumber returns a number whose encoding has many digits or
+// a large exponent.
+func (g *jsonGen) consumeHugeNumber() (float64, []byte) {
+	fdp := g.fdp
+
+	var b []byte
+
+	if fdp.ConsumeBool() {
+		b = append(b, '-')
+	}
+
+	b = append(b, byte('1'+fdp.ConsumeIntInRange(0, 8)))
+
+	for range fdp.ConsumeIntInRange(0, maxHugeDigits-1) {
+		b = append(b, byte('0'+fdp.ConsumeIntInRange(0, 9)))
+	}
+
+	if fdp.ConsumeBool() {
+		b = append(b, 'e')
+		b = strconv.AppendInt(b, int64(fdp.ConsumeIntInRange(-maxHugeExponent,
+			maxHugeExponent)), 10)
+	}
+
+	// ParseFloat returns the nearest value, or infinity with
+	// ErrRange.
+	v, _ := strconv.ParseFloat(string(b), 64)
+
+	return v, b
+}
+
+// appendString appends s as JSON string to b.  Runes which do not fit
+// in limit are dropped.  It returns the string which conforming
+// parsers decode from the encoding.
+func (g *jsonGen) appendString(b []byte, s string, limit int) (string, []byte) {
+	fdp := g.fdp
+	s = strings.ToValidUTF8(s, string(utf8.RuneError))
+	// The closing quote is reserved.
+	end := len(b) + limit - 1
+	escapeAll := fdp.ConsumeBool()
+
+	// Positions in runes where an invalid escape and a lone surrogate
+	// are inserted.  -1 means none.
+	invalidAt, surrogateAt := -1, -1
+	n := utf8.RuneCountInString(s)
+
+	if g.opts.InvalidEscapes && fdp.ConsumeBool() {
+		invalidAt = fdp.ConsumeIntInRange(0, n)
+	}
+
+	if g.opts.LoneSurrogates && fdp.ConsumeBool() {
+		surrogateAt = fdp.ConsumeIntInRange(0, n)
+	}
+
+	var (
+		v   strings.Builder
+		enc []byte
+	)
+
+	b = append(b, '"')
+
+	// Runes are appended until one does not fit, and the loop runs
+	// once more past the end of s for insertions at position n.
+	i := 0
+
+	for _, r := range s + "\x00" {
+		if i == surrogateAt {
+			// The escape of r never starts with a low surrogate, so that
+			// the inserted one stays lone.
+			enc = appendJSONEscape(enc[:0],
+				rune(fdp.ConsumeIntInRange(0xd800, 0xdfff)))
+
+			if len(b)+len(enc) > end {
+				break
+			}
+
+			b = append(b, enc...)
+			v.WriteRune(utf8.RuneError)
+		}
+
+		if i == invalidAt {
+			c := invalidEscapes[fdp.ConsumeIntInRange(0, len(invalidEscapes)-1)]
+
+			if len(b)+2 > end {
+				break
+			}
+
+			b = append(b, '\\', c)
+			v.WriteByte(c)
+		}
+
+		if i == n {
+			break
+		}
+
+		enc = appendJSONRune(enc[:0], r, escapeAll)
+		if len(b)+len(enc) > end {
+			break
+		}
+
+		b = append(b, enc...)
+		v.WriteRune(r)
+
+		i++
+	}
+
+	return v.String(), append(b, '"')
+}
+
+// appendJSONRune appends r in JSON string to b.  If escapeAll is true,
+// '/' and non-ASCII runes are also escaped.
+func appendJSONRune(b []byte, r rune, escapeAll bool) []byte {
+	switch r {
+	case '"', '\\':
+		return append(b, '\\', byte(r))
+	case '\b':
+		return append(b, `\b`...)
+	case '\f':
+		return append(b, `\f`...)
+	case '\n':
+		return append(b, `\n`...)
+	case '\r':
+		return append(b, `\r`...)
+	case '\t':
+		return append(b, `\t`...)
+	case '/':
+		if escapeAll {
+			return append(b, `\/`...)
+		}
+	}
+
+	switch {
+	case r < ' ':
+		return appendJSONEscape(b, r)
+	case r < utf8.RuneSelf || !escapeAll:
+		return utf8.AppendRune(b, r)
+	case r > 0xffff:
+		r1, r2 := utf16.EncodeRune(r)
+
+		return appendJSONEscape(appendJSONEscape(b, r1), r2)
+	default:
+		return appendJSONEscape(b, r)
+	}
+}
+
+// appendJSONEscape appends \u escape of UTF-16 code unit r to b.
+func appendJSONEscape(b []byte, r rune) []byte {
+	const hex = "0123456789abcdef"
+
+	return append(b, '\\', 'u', hex[r>>12&0xf], hex[r>>8&0xf], hex[r>>4&0xf],
+		hex[r&0xf])
+}
+
+// appendArray appends JSON array to b.  The appended encoding is at
+// most limit bytes long.
+func (g *jsonGen) appendArray(b []byte, depth, limit int) ([]any, []byte) {
+	// The closing bracket is reserved.
+	end := len(b) + limit - 1
+	v := []any{}
+
+	b = append(b, '[')
+
+	for g.fdp.ConsumeUint8() != 0 {
+		if len(v) != 0 {
+			if len(b)+len(",0") > end {
+				break
+			}
+
+			b = append(b, ',')
+		} else if len(b) >= end {
+			break
+		}
+
+		var e any
+
+		e, b = g.appendValue(b, depth+1, end-len(b))
+		v = append(v, e)
+	}
+
+	return v, append(b, ']')
+}
+
+// appendObject appends JSON object to b.  The appended encoding is at
+// most limit bytes long.
+func (g *jsonGen) appendObject(
+	b []byte, depth, limit int,
+) (map[string]any, []byte) {
+	fdp := g.fdp
+	// The closing brace is reserved.
+	end := len(b) + limit - 1
+	v := make(map[string]any)
+
+	var keys []string
+
+	b = append(b, '{')
+
+	for fdp.ConsumeUint8() != 0 {
+		start := len(b)
+		if len(keys) != 0 {
+			b = append(b, ',')
+		}
+
+		// Each member needs at least `"":0`.
+		if len(b)+len(`"":0`) > end {
+			b = b[:start]
+
+			break
+		}
+
+		var (
+			key string
+			enc []byte
+		)
+
+		if len(keys) != 0 && g.opts.DuplicateKeys && fdp.ConsumeBool() {
+			key = keys[fdp.ConsumeIntInRange(0, len(keys)-1)]
+			enc = appendJSONKey(nil, key)
+		} else {
+			// The colon and a value are reserved.
+			key, enc = g.appendString(nil,
+				fdp.ConsumeDictionaryString(end-len(b)-2), end-len(b)-2)
+		}
+
+		_, dup := v[key]
+		if len(b)+len(enc)+len(":0") > end ||
+			dup && !g.opts.DuplicateKeys {
+			b = b[:start]
+
+			continue
+		}
+
+		b = append(b, enc...)
+		b = append(b, ':')
+
+		var e any
+
+		e, b = g.appendValue(b, depth+1, end-len(b))
+		v[key] = e
+		keys = append(keys, key)
+	}
+
+	return v, append(b, '}')
+}
+
+// appendJSONKey appends key as JSON string to b without escaping
+// non-ASCII runes.
+func appendJSONKey(b []byte, key string) []byte {
+	b = append(b, '"')
+
+	for _, r := range key {
+		b = appendJSONRune(b, r, false)
+	}
+
+	return append(b, '"')
+}
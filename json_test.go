@@ -0,0 +1,88 @@
+package fuzz
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ngtcp2/fuzzeddataprovider-go/internal/gentest"
+)
+
+func TestConsumeJSON(t *testing.T) {
+	fdp := NewFuzzedDataProvider(nil)
+
+	v, b := fdp.ConsumeJSON(8, 64, nil)
+	assert.Nil(t, v)
+	assert.Equal(t, "null", string(b))
+
+	v, b = fdp.ConsumeJSON(8, 3, nil)
+	assert.Equal(t, float64(0), v)
+	assert.Equal(t, "0", string(b))
+
+	fdp = NewFuzzedDataProvider([]byte{'a', '"', 0x03})
+
+	v, b = fdp.ConsumeJSON(8, 64, nil)
+	assert.Equal(t, `a"`, v)
+	assert.Equal(t, `"a\""`, string(b))
+}
+
+func TestConsumeJSONAppendString(t *testing.T) {
+	g := &jsonGen{
+		fdp:  NewFuzzedDataProvider([]byte{0x01}),
+		opts: &JSONOptions{},
+	}
+
+	v, b := g.appendString(nil, "\x01/é😀\xff", 64)
+	assert.Equal(t, "\x01/é😀�", v)
+	assert.Equal(t, `"\u0001\/\u00e9\ud83d\ude00\ufffd"`, string(b))
+
+	v, b = g.appendString(nil, "abc", 3)
+	assert.Equal(t, "a", v)
+	assert.Equal(t, `"a"`, string(b))
+}
+
+func TestConsumeJSONValid(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	data := make([]byte, 4096)
+	opts := &JSONOptions{
+		DuplicateKeys:  true,
+		LoneSurrogates: true,
+	}
+
+	for i := range 1000 {
+		for i := range data {
+			data[i] = byte(r.Uint32())
+		}
+
+		maxSize := i % 300
+
+		v, b := NewFuzzedDataProvider(data).ConsumeJSON(8, maxSize, opts)
+
+		require.LessOrEqual(t, len(b), max(maxSize, 1))
+
+		var got any
+
+		require.NoError(t, json.Unmarshal(b, &got), "%s", b)
+		require.Equal(t, got, v, "%s", b)
+	}
+}
+
+func TestConsumeJSONProperty(t *testing.T) {
+	gentest.Check(t, func(data []byte, illegal bool) []byte {
+		_, b := NewFuzzedDataProvider(data).ConsumeJSON(8, 1024, &JSONOptions{
+			HugeNumbers:    true,
+			InvalidEscapes: illegal,
+		})
+
+		return b
+	}, func(b []byte) error {
+		if !json.Valid(b) {
+			return gentest.ErrMalformed
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,446 @@
+// Package msgpack provides helpers which generate MessagePack objects
+// from FuzzedDataProvider.
+package msgpack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	fuzz "github.com/ngtcp2/fuzzeddataprovider-go"
+	"github.com/ngtcp2/fuzzeddataprovider-go/internal/gen"
+)
+
+// Formats defined in the MessagePack specification.  Fix formats are
+// the first byte of the range.
+const (
+	FormatPositiveFixint = 0x00
+	FormatFixmap         = 0x80
+	FormatFixarray       = 0x90
+	FormatFixstr         = 0xa0
+	FormatNil            = 0xc0
+	// FormatNeverUsed is the byte which is never used.
+	FormatNeverUsed      = 0xc1
+	FormatFalse          = 0xc2
+	FormatTrue           = 0xc3
+	FormatBin8           = 0xc4
+	FormatBin16          = 0xc5
+	FormatBin32          = 0xc6
+	FormatExt8           = 0xc7
+	FormatExt16          = 0xc8
+	FormatExt32          = 0xc9
+	FormatFloat32        = 0xca
+	FormatFloat64        = 0xcb
+	FormatUint8          = 0xcc
+	FormatUint16         = 0xcd
+	FormatUint32         = 0xce
+	FormatUint64         = 0xcf
+	FormatInt8           = 0xd0
+	FormatInt16          = 0xd1
+	FormatInt32          = 0xd2
+	FormatInt64          = 0xd3
+	FormatFixext1        = 0xd4
+	FormatFixext2        = 0xd5
+	FormatFixext4        = 0xd6
+	FormatFixext8        = 0xd7
+	FormatFixext16       = 0xd8
+	FormatStr8           = 0xd9
+	FormatStr16          = 0xda
+	FormatStr32          = 0xdb
+	FormatArray16        = 0xdc
+	FormatArray32        = 0xdd
+	FormatMap16          = 0xde
+	FormatMap32          = 0xdf
+	FormatNegativeFixint = 0xe0
+)
+
+// ExtTimestamp is the extension type of Timestamp.
+const ExtTimestamp = -1
+
+const (
+	typeNil = iota
+	typeBool
+	typeInt
+	typeFloat
+	typeStr
+	typeBin
+	typeExt
+	typeArray
+	typeMap
+)
+
+// lengthFormats are the formats of str, bin, array and map with 8, 16
+// and 32 bits length respectively.  0 means that the format does not
+// exist.
+var lengthFormats = map[int][3]byte{
+	typeStr:   {FormatStr8, FormatStr16, FormatStr32},
+	typeBin:   {FormatBin8, FormatBin16, FormatBin32},
+	typeArray: {0, FormatArray16, FormatArray32},
+	typeMap:   {0, FormatMap16, FormatMap32},
+	typeExt:   {FormatExt8, FormatExt16, FormatExt32},
+}
+
+// fixFormats are the fix formats of str, array and map, and their
+// maximum lengths.
+var fixFormats = map[int]struct {
+	format byte
+	maxLen int
+}{
+	typeStr:   {FormatFixstr, 31},
+	typeArray: {FormatFixarray, 15},
+	typeMap:   {FormatFixmap, 15},
+}
+
+// fixextLens are the data lengths of fixext 1, 2, 4, 8 and 16.
+var fixextLens = []int{1, 2, 4, 8, 16}
+
+// timestampLens are the data lengths of timestamp 32, 64 and 96.
+var timestampLens = []int{4, 8, 12}
+
+const (
+	// maxNsec is the maximum nanoseconds of Timestamp.
+	maxNsec = 999999999
+)
+
+// Options controls the objects generated by this package.  The zero
+// value generates well-formed objects in the shortest formats with
+// default limits.
+type Options struct {
+	// MaxDepth is the maximum nesting depth of arrays and maps.  If it
+	// is 0, 8 is used.
+	MaxDepth int
+	// MaxItems is the maximum number of elements of an array, and
+	// pairs of a map.  If it is 0, 16 is used.
+	MaxItems int
+	// MaxDataLen is the maximum length of str, bin and ext data.  If
+	// it is 0, 256 is used.
+	MaxDataLen int
+	// NonCanonical allows formats wider than necessary, such as uint32
+	// for 1, int8 for positive integers, str8 for short strings and
+	// ext8 for data which fits in fixext.
+	NonCanonical bool
+	// AllowIllegal allows malformed objects, such as the never used
+	// byte 0xc1, lengths which do not match the data, invalid UTF-8 in
+	// str, and Timestamp with invalid length or nanoseconds.
+	AllowIllegal bool
+}
+
+type objectGen struct {
+	fdp        *fuzz.FuzzedDataProvider
+	opts       *Options
+	maxDepth   int
+	maxItems   int
+	maxDataLen int
+	// illegal is true if the object being generated may be malformed.
+	illegal bool
+}
+
+func newObjectGen(fdp *fuzz.FuzzedDataProvider, opts *Options) *objectGen {
+	opts = gen.OrZero(opts)
+
+	return &objectGen{
+		fdp:        fdp,
+		opts:       opts,
+		maxDepth:   gen.Limit(opts.MaxDepth, gen.DefaultMaxDepth),
+		maxItems:   gen.Limit(opts.MaxItems, gen.DefaultMaxCount),
+		maxDataLen: gen.Limit(opts.MaxDataLen, gen.DefaultMaxDataLen),
+	}
+}
+
+// ConsumeObject returns an encoded MessagePack object generated by
+// consuming bytes from the input data.  Map keys are distinct in their
+// encoded form unless opts.AllowIllegal is true.  opts may be nil.  If
+// there is no input data left, it returns nil.
+func ConsumeObject(fdp *fuzz.FuzzedDataProvider, opts *Options) []byte {
+	return newObjectGen(fdp, opts).appendObject(nil, 0)
+}
+
+func (g *objectGen) appendObject(b []byte, depth int) []byte {
+	fdp := g.fdp
+
+	g.illegal = gen.ConsumeIllegal(fdp, g.opts.AllowIllegal)
+	if g.illegal && fdp.ConsumeBool() {
+		return append(b, FormatNeverUsed)
+	}
+
+	maxType := typeMap
+	if depth >= g.maxDepth {
+		maxType = typeExt
+	}
+
+	switch typ := fdp.ConsumeIntInRange(typeNil, maxType); typ {
+	case typeBool:
+		if fdp.ConsumeBool() {
+			return append(b, FormatTrue)
+		}
+
+		return append(b, FormatFalse)
+	case typeInt:
+		if fdp.ConsumeBool() {
+			return g.appendInt(b, fdp.ConsumeInt64())
+		}
+
+		return g.appendUint(b, fdp.ConsumeUint64())
+	case typeFloat:
+		if fdp.ConsumeBool() {
+			return binary.BigEndian.AppendUint32(append(b, FormatFloat32),
+				math.Float32bits(fdp.ConsumeFloat32()))
+		}
+
+		return binary.BigEndian.AppendUint64(append(b, FormatFloat64),
+			math.Float64bits(fdp.ConsumeFloat64()))
+	case typeStr:
+		s := fdp.ConsumeDictionaryString(g.maxDataLen)
+		if !g.illegal {
+			s = strings.ToValidUTF8(s, string(utf8.RuneError))
+		}
+
+		b = g.appendLength(b, typ, len(s))
+
+		return append(b, s...)
+	case typeBin:
+		data := fdp.ConsumeBytes(fdp.ConsumeIntInRange(0, g.maxDataLen))
+		b = g.appendLength(b, typ, len(data))
+
+		return append(b, data...)
+	case typeExt:
+		return g.appendExt(b)
+	case typeArray:
+		return g.appendArray(b, depth)
+	case typeMap:
+		return g.appendMap(b, depth)
+	default:
+		return append(b, FormatNil)
+	}
+}
+
+// appendUint appends unsigned integer v to b.
+func (g *objectGen) appendUint(b []byte, v uint64) []byte {
+	if v > math.MaxInt64 {
+		return binary.BigEndian.AppendUint64(append(b, FormatUint64), v)
+	}
+
+	return g.appendInt(b, int64(v))
+}
+
+// appendInt appends integer v to b in the shortest format, or in a
+// wider format if g.opts.NonCanonical is true.  Non-negative v might be
+// encoded in signed formats.
+func (g *objectGen) appendInt(b []byte, v int64) []byte {
+	var formats []byte
+
+	switch {
+	case v >= 0 && v <= math.MaxInt8:
+		formats = []byte{FormatPositiveFixint, FormatUint8, FormatUint16,
+			FormatUint32, FormatUint64, FormatInt8, FormatInt16, FormatInt32,
+			FormatInt64}
+	case v >= 0 && v <= math.MaxUint8:
+		formats = []byte{FormatUint8, FormatUint16, FormatUint32,
+			FormatUint64, FormatInt16, FormatInt32, FormatInt64}
+	case v >= 0 && v <= math.MaxInt16:
+		formats = []byte{FormatUint16, FormatUint32, FormatUint64,
+			FormatInt16, FormatInt32, FormatInt64}
+	case v >= 0 && v <= math.MaxUint16:
+		formats = []byte{FormatUint16, FormatUint32, FormatUint64,
+			FormatInt32, FormatInt64}
+	case v >= 0 && v <= math.MaxInt32:
+		formats = []byte{FormatUint32, FormatUint64, FormatInt32,
+			FormatInt64}
+	case v >= 0 && v <= math.MaxUint32:
+		formats = []byte{FormatUint32, FormatUint64, FormatInt64}
+	case v >= 0:
+		formats = []byte{FormatUint64, FormatInt64}
+	case v >= -32:
+		formats = []byte{FormatNegativeFixint, FormatInt8, FormatInt16,
+			FormatInt32, FormatInt64}
+	case v >= math.MinInt8:
+		formats = []byte{FormatInt8, FormatInt16, FormatInt32, FormatInt64}
+	case v >= math.MinInt16:
+		formats = []byte{FormatInt16, FormatInt32, FormatInt64}
+	case v >= math.MinInt32:
+		formats = []byte{FormatInt32, FormatInt64}
+	default:
+		formats = []byte{FormatInt64}
+	}
+
+	format := formats[0]
+	if g.opts.NonCanonical && g.fdp.ConsumeBool() {
+		format = formats[g.fdp.ConsumeIntInRange(0, len(formats)-1)]
+	}
+
+	switch format {
+	case FormatPositiveFixint, FormatNegativeFixint:
+		return append(b, byte(v))
+	case FormatUint8, FormatInt8:
+		return append(b, format, byte(v))
+	case FormatUint16, FormatInt16:
+		return binary.BigEndian.AppendUint16(append(b, format), uint16(v))
+	case FormatUint32, FormatInt32:
+		return binary.BigEndian.AppendUint32(append(b, format), uint32(v))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, format), uint64(v))
+	}
+}
+
+// appendLength appends the format and the length n of str, bin, ext,
+// array or map to b.  If g.illegal is true, the length might not match
+// the data.
+func (g *objectGen) appendLength(b []byte, typ, n int) []byte {
+	fdp := g.fdp
+
+	n = int(gen.ConsumeLength(fdp, g.illegal, uint64(n), math.MaxUint32))
+
+	fix, hasFix := fixFormats[typ]
+	formats := lengthFormats[typ]
+
+	// w is the index of the shortest format in formats.
+	w := 0
+
+	switch {
+	case hasFix && n <= fix.maxLen &&
+		(!g.opts.NonCanonical || fdp.ConsumeBool()):
+		return append(b, fix.format|byte(n))
+	case n > math.MaxUint16:
+		w = 2
+	case n > math.MaxUint8 || formats[0] == 0:
+		w = 1
+	}
+
+	if g.opts.NonCanonical && fdp.ConsumeBool() {
+		w = fdp.ConsumeIntInRange(w, len(formats)-1)
+	}
+
+	switch w {
+	case 0:
+		return append(b, formats[w], byte(n))
+	case 1:
+		return binary.BigEndian.AppendUint16(append(b, formats[w]), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, formats[w]), uint32(n))
+	}
+}
+
+// appendExt appends Timestamp or an extension object of application
+// defined type to b.  Timestamp has a valid layout unless g.illegal is
+// true.
+func (g *objectGen) appendExt(b []byte) []byte {
+	fdp := g.fdp
+
+	var (
+		typ  int8
+		data []byte
+	)
+
+	if fdp.ConsumeBool() {
+		typ = ExtTimestamp
+		data = g.consumeTimestamp()
+	} else {
+		// Negative types are reserved for predefined types.
+		typ = fdp.ConsumeInt8InRange(0, math.MaxInt8)
+		if g.illegal {
+			typ = fdp.ConsumeInt8()
+		}
+
+		data = fdp.ConsumeBytes(fdp.ConsumeIntInRange(0, g.maxDataLen))
+	}
+
+	if i := slices.Index(fixextLens, len(data)); i != -1 &&
+		(!g.opts.NonCanonical || fdp.ConsumeBool()) {
+		b = append(b, FormatFixext1+byte(i))
+	} else {
+		b = g.appendLength(b, typeExt, len(data))
+	}
+
+	b = append(b, byte(typ))
+
+	return append(b, data...)
+}
+
+// consumeTimestamp returns the data of Timestamp extension.
+func (g *objectGen) consumeTimestamp() []byte {
+	fdp := g.fdp
+
+	maxNs := uint32(maxNsec)
+	if g.illegal {
+		maxNs = math.MaxUint32
+	}
+
+	n := timestampLens[fdp.ConsumeIntInRange(0, len(timestampLens)-1)]
+	if g.illegal && fdp.ConsumeBool() {
+		n = fdp.ConsumeIntInRange(0, g.maxDataLen)
+	}
+
+	switch n {
+	case 4:
+		return binary.BigEndian.AppendUint32(nil, fdp.ConsumeUint32())
+	case 8:
+		ns := min(fdp.ConsumeUint32InRange(0, maxNs), 1<<30-1)
+		sec := fdp.ConsumeUint64InRange(0, 1<<34-1)
+
+		return binary.BigEndian.AppendUint64(nil, uint64(ns)<<34|sec)
+	case 12:
+		b := binary.BigEndian.AppendUint32(nil, fdp.ConsumeUint32InRange(0, maxNs))
+
+		return binary.BigEndian.AppendUint64(b, fdp.ConsumeUint64())
+	default:
+		return fdp.ConsumeBytes(n)
+	}
+}
+
+// appendArray appends an array to b.
+func (g *objectGen) appendArray(b []byte, depth int) []byte {
+	var items []byte
+
+	illegal := g.illegal
+	n := 0
+
+	for ; n < g.maxItems; n++ {
+		if g.fdp.ConsumeUint8() == 0 {
+			break
+		}
+
+		items = g.appendObject(items, depth+1)
+	}
+
+	g.illegal = illegal
+	b = g.appendLength(b, typeArray, n)
+
+	return append(b, items...)
+}
+
+// appendMap appends a map to b.
+func (g *objectGen) appendMap(b []byte, depth int) []byte {
+	var (
+		keys  [][]byte
+		pairs []byte
+	)
+
+	illegal := g.illegal
+
+	for range g.maxItems {
+		if g.fdp.ConsumeUint8() == 0 {
+			break
+		}
+
+		key := g.appendObject(nil, depth+1)
+		value := g.appendObject(nil, depth+1)
+
+		if !illegal && slices.ContainsFunc(keys, func(k []byte) bool {
+			return bytes.Equal(k, key)
+		}) {
+			continue
+		}
+
+		keys = append(keys, key)
+		pairs = append(pairs, key...)
+		pairs = append(pairs, value...)
+	}
+
+	g.illegal = illegal
+	b = g.appendLength(b, typeMap, len(keys))
+
+	return append(b, pairs...)
+}
@@ -0,0 +1,188 @@
+package msgpack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+
+	fuzz "github.com/ngtcp2/fuzzeddataprovider-go"
+	"github.com/ngtcp2/fuzzeddataprovider-go/internal/gentest"
+)
+
+type reader struct {
+	gentest.Reader
+	// canonical requires the shortest formats.
+	canonical bool
+}
+
+// length reads the length of n bytes, and checks that a shorter
+// format, whose maximum length is shorter, could not be used.
+func (r *reader) length(n int, shorter int) uint64 {
+	v := r.Uint(n)
+	r.Check(!r.canonical || int(v) > shorter)
+
+	return v
+}
+
+// object validates the next object according to the MessagePack
+// specification, and returns its encoding.
+func (r *reader) object() []byte {
+	b := r.B
+	c := r.Byte()
+
+	switch {
+	case c <= 0x7f, c >= FormatNegativeFixint, c == FormatNil,
+		c == FormatFalse, c == FormatTrue:
+	case c >= FormatFixmap && c < FormatFixarray:
+		r.mapPairs(uint64(c & 0x0f))
+	case c >= FormatFixarray && c < FormatFixstr:
+		r.arrayItems(uint64(c & 0x0f))
+	case c >= FormatFixstr && c < FormatNil:
+		r.str(uint64(c & 0x1f))
+	case c == FormatUint8:
+		v := r.Uint(1)
+		r.Check(!r.canonical || v > math.MaxInt8)
+	case c == FormatUint16:
+		v := r.Uint(2)
+		r.Check(!r.canonical || v > math.MaxUint8)
+	case c == FormatUint32:
+		v := r.Uint(4)
+		r.Check(!r.canonical || v > math.MaxUint16)
+	case c == FormatUint64:
+		v := r.Uint(8)
+		r.Check(!r.canonical || v > math.MaxUint32)
+	case c == FormatInt8:
+		v := int8(r.Uint(1))
+		r.Check(!r.canonical || v < -32)
+	case c == FormatInt16:
+		v := int16(r.Uint(2))
+		r.Check(!r.canonical || v < math.MinInt8)
+	case c == FormatInt32:
+		v := int32(r.Uint(4))
+		r.Check(!r.canonical || v < math.MinInt16)
+	case c == FormatInt64:
+		v := int64(r.Uint(8))
+		r.Check(!r.canonical || v < math.MinInt32)
+	case c == FormatFloat32:
+		r.Bytes(4)
+	case c == FormatFloat64:
+		r.Bytes(8)
+	case c == FormatStr8:
+		r.str(r.length(1, 31))
+	case c == FormatStr16:
+		r.str(r.length(2, math.MaxUint8))
+	case c == FormatStr32:
+		r.str(r.length(4, math.MaxUint16))
+	case c == FormatBin8:
+		r.Bytes(r.length(1, -1))
+	case c == FormatBin16:
+		r.Bytes(r.length(2, math.MaxUint8))
+	case c == FormatBin32:
+		r.Bytes(r.length(4, math.MaxUint16))
+	case c == FormatArray16:
+		r.arrayItems(r.length(2, 15))
+	case c == FormatArray32:
+		r.arrayItems(r.length(4, math.MaxUint16))
+	case c == FormatMap16:
+		r.mapPairs(r.length(2, 15))
+	case c == FormatMap32:
+		r.mapPairs(r.length(4, math.MaxUint16))
+	case c >= FormatFixext1 && c <= FormatFixext16:
+		r.ext(1 << (c - FormatFixext1))
+	case c == FormatExt8:
+		n := r.Uint(1)
+		r.Check(!r.canonical || !isFixextLen(n))
+		r.ext(n)
+	case c == FormatExt16:
+		r.ext(r.length(2, math.MaxUint8))
+	case c == FormatExt32:
+		r.ext(r.length(4, math.MaxUint16))
+	default:
+		panic(gentest.ErrMalformed)
+	}
+
+	return b[:len(b)-len(r.B)]
+}
+
+func isFixextLen(n uint64) bool {
+	return n == 1 || n == 2 || n == 4 || n == 8 || n == 16
+}
+
+func (r *reader) str(n uint64) {
+	r.Check(utf8.Valid(r.Bytes(n)))
+}
+
+func (r *reader) ext(n uint64) {
+	typ := int8(r.Uint(1))
+	data := r.Bytes(n)
+
+	if typ != ExtTimestamp {
+		return
+	}
+
+	switch n {
+	case 4:
+	case 8:
+		r.Check(binary.BigEndian.Uint64(data)>>34 <= maxNsec)
+	case 12:
+		r.Check(binary.BigEndian.Uint32(data) <= maxNsec)
+	default:
+		panic(gentest.ErrMalformed)
+	}
+}
+
+func (r *reader) arrayItems(n uint64) {
+	for range n {
+		r.object()
+	}
+}
+
+func (r *reader) mapPairs(n uint64) {
+	var keys [][]byte
+
+	for range n {
+		key := r.object()
+		for _, k := range keys {
+			r.Check(!bytes.Equal(k, key))
+		}
+
+		keys = append(keys, key)
+		r.object()
+	}
+}
+
+// parse validates that b is a single object.  If canonical is true,
+// it also requires the shortest formats.
+func parse(b []byte, canonical bool) error {
+	return gentest.Parse(func() {
+		r := &reader{Reader: gentest.Reader{B: b}, canonical: canonical}
+		r.object()
+		r.Check(len(r.B) == 0)
+	})
+}
+
+func TestConsumeObject(t *testing.T) {
+	fdp := fuzz.NewFuzzedDataProvider(nil)
+
+	assert.Equal(t, []byte{FormatNil}, ConsumeObject(fdp, nil))
+
+	// str "ab".
+	fdp = fuzz.NewFuzzedDataProvider([]byte{'a', 'b', 0x04})
+
+	assert.Equal(t, []byte{0xa2, 'a', 'b'}, ConsumeObject(fdp, nil))
+}
+
+func TestConsumeObjectProperty(t *testing.T) {
+	for _, nonCanonical := range []bool{false, true} {
+		gentest.Check(t, func(data []byte, illegal bool) []byte {
+			return ConsumeObject(fuzz.NewFuzzedDataProvider(data), &Options{
+				NonCanonical: nonCanonical,
+				AllowIllegal: illegal,
+			})
+		}, func(b []byte) error { return parse(b, !nonCanonical) })
+	}
+}
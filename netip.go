@@ -0,0 +1,146 @@
+package fuzz
+
+import "net/netip"
+
+const (
+	addrIPv4 = iota
+	addrIPv6
+	addrIPv4Mapped
+	addrIPv6Zone
+)
+
+// maxZoneLength is the maximum length of IPv6 zone returned by
+// ConsumeAddr.
+const maxZoneLength = 16
+
+// consumeAddrBytes returns n bytes consumed from the input data.  If
+// fewer than n bytes remain, the rest is filled with zeros.
+func (fdp *FuzzedDataProvider) consumeAddrBytes(n int) [16]byte {
+	var b [16]byte
+
+	copy(b[:n], fdp.ConsumeBytes(n))
+
+	return b
+}
+
+// ConsumeAddr returns an IPv4 address, an IPv6 address, an
+// IPv4-mapped IPv6 address or an IPv6 address with zone by consuming
+// bytes from the input data.  If there is no input data left, it
+// always returns 0.0.0.0.
+func (fdp *FuzzedDataProvider) ConsumeAddr() netip.Addr {
+	switch fdp.ConsumeIntInRange(addrIPv4, addrIPv6Zone) {
+	case addrIPv6:
+		return netip.AddrFrom16(fdp.consumeAddrBytes(16))
+	case addrIPv4Mapped:
+		b := fdp.consumeAddrBytes(4)
+
+		return netip.AddrFrom16(netip.AddrFrom4([4]byte(b[:4])).As16())
+	case addrIPv6Zone:
+		addr := netip.AddrFrom16(fdp.consumeAddrBytes(16))
+		zone := fdp.ConsumeRandomLengthString(maxZoneLength)
+
+		return addr.WithZone(zone)
+	default:
+		b := fdp.consumeAddrBytes(4)
+
+		return netip.AddrFrom4([4]byte(b[:4]))
+	}
+}
+
+// ConsumeAddrPort returns an address returned by ConsumeAddr with a
+// port number consumed from the input data.
+func (fdp *FuzzedDataProvider) ConsumeAddrPort() netip.AddrPort {
+	addr := fdp.ConsumeAddr()
+
+	return netip.AddrPortFrom(addr, fdp.ConsumeUint16())
+}
+
+// ConsumePrefix returns a prefix whose address is returned by
+// ConsumeAddr without zone, and whose length is in the range [0,
+// addr.BitLen()].  The host bits of the address are not masked.
+func (fdp *FuzzedDataProvider) ConsumePrefix() netip.Prefix {
+	addr := fdp.ConsumeAddr().WithZone("")
+
+	return netip.PrefixFrom(addr, fdp.ConsumeIntInRange(0, addr.BitLen()))
+}
+
+// ConsumeAddrInPrefix returns an address in p whose host bits are
+// consumed from the input data.  If there is no input data left, it
+// always returns the first address in p.  p must be valid.
+func (fdp *FuzzedDataProvider) ConsumeAddrInPrefix(p netip.Prefix) netip.Addr {
+	p = p.Masked()
+	b := p.Addr().AsSlice()
+	host := fdp.consumeAddrBytes(len(b))
+
+	for i := range b {
+		bits := min(max(p.Bits()-i*8, 0), 8)
+		b[i] |= host[i] &^ byte(uint(0xff00)>>bits)
+	}
+
+	addr, _ := netip.AddrFromSlice(b)
+
+	return addr
+}
+
+// specialPrefixes are the address ranges which have special meaning.
+var specialPrefixes = []netip.Prefix{
+	// IPv4 unspecified, "this network", loopback and broadcast.
+	netip.MustParsePrefix("0.0.0.0/32"),
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("255.255.255.255/32"),
+	// IPv4 private, shared, link-local, documentation and multicast.
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	// IPv6 unspecified and loopback.
+	netip.MustParsePrefix("::/128"),
+	netip.MustParsePrefix("::1/128"),
+	// IPv4-mapped and NAT64.
+	netip.MustParsePrefix("::ffff:0.0.0.0/96"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	// IPv6 link-local, unique local, documentation and multicast.
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("ff00::/8"),
+	netip.MustParsePrefix("ff02::/16"),
+}
+
+// ConsumeSpecialAddr returns an address in one of the special ranges,
+// such as loopback, link-local, multicast and unspecified, chosen by
+// consuming bytes from the input data.  Use it instead of ConsumeAddr
+// to bias toward the addresses which access control and routing code
+// often treat specially.  If there is no input data left, it always
+// returns 0.0.0.0.
+func (fdp *FuzzedDataProvider) ConsumeSpecialAddr() netip.Addr {
+	p := specialPrefixes[fdp.ConsumeIntInRange(0, len(specialPrefixes)-1)]
+
+	return fdp.ConsumeAddrInPrefix(p)
+}
+
+// ConsumeSpecialAddrPort returns an address returned by
+// ConsumeSpecialAddr with a port number consumed from the input data.
+func (fdp *FuzzedDataProvider) ConsumeSpecialAddrPort() netip.AddrPort {
+	addr := fdp.ConsumeSpecialAddr()
+
+	return netip.AddrPortFrom(addr, fdp.ConsumeUint16())
+}
+
+// ConsumeSpecialPrefix returns one of the special ranges, or a prefix
+// inside it, chosen by consuming bytes from the input data.  Its
+// address is in the range, and its length is at least the length of
+// the range and at most the bit length of the address.  The host bits
+// of the address are not masked.  If there is no input data left, it always returns
+// 0.0.0.0/32.
+func (fdp *FuzzedDataProvider) ConsumeSpecialPrefix() netip.Prefix {
+	p := specialPrefixes[fdp.ConsumeIntInRange(0, len(specialPrefixes)-1)]
+	addr := fdp.ConsumeAddrInPrefix(p)
+
+	return netip.PrefixFrom(addr,
+		fdp.ConsumeIntInRange(p.Bits(), addr.BitLen()))
+}
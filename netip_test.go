@@ -0,0 +1,87 @@
+package fuzz
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsumeAddr(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{
+		0xc0, 0x00, 0x02, 0x01,
+		0x20, 0x01, 0x0d, 0xb8, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01,
+		0xfe, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01,
+		'e', 't', 'h', '0', '\\', 'X',
+		0xc0, 0x00, 0x02, 0x01,
+		0x02, 0x03, 0x01, 0x00,
+	})
+
+	assert.Equal(t, netip.MustParseAddr("192.0.2.1"), fdp.ConsumeAddr())
+	assert.Equal(t, netip.MustParseAddr("2001:db8::1"), fdp.ConsumeAddr())
+	assert.Equal(t, netip.MustParseAddr("fe80::1%eth0"), fdp.ConsumeAddr())
+	assert.Equal(t, netip.MustParseAddr("::ffff:192.0.2.1"),
+		fdp.ConsumeAddr())
+	assert.Equal(t, netip.IPv4Unspecified(), fdp.ConsumeAddr())
+}
+
+func TestConsumeAddrPort(t *testing.T) {
+	fdp := NewFuzzedDataProvider(
+		[]byte{0xc0, 0x00, 0x02, 0x01, 0xbb, 0x01, 0x00})
+
+	assert.Equal(t, netip.MustParseAddrPort("192.0.2.1:443"),
+		fdp.ConsumeAddrPort())
+}
+
+func TestConsumePrefix(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{0xc0, 0x00, 0x02, 0x01, 0x18, 0x00})
+
+	assert.Equal(t, netip.MustParsePrefix("192.0.2.1/24"),
+		fdp.ConsumePrefix())
+	assert.Equal(t, netip.MustParsePrefix("0.0.0.0/0"), fdp.ConsumePrefix())
+}
+
+func TestConsumeAddrInPrefix(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{
+		0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	})
+
+	assert.Equal(t, netip.MustParseAddr("172.31.255.255"),
+		fdp.ConsumeAddrInPrefix(netip.MustParsePrefix("172.16.0.1/12")))
+	assert.Equal(t,
+		netip.MustParseAddr("febf:ffff:ffff:ffff:ffff:ffff:ffff:ffff"),
+		fdp.ConsumeAddrInPrefix(netip.MustParsePrefix("fe80::/10")))
+	assert.Equal(t, netip.MustParseAddr("::ffff:0.0.0.0"),
+		fdp.ConsumeAddrInPrefix(
+			netip.MustParsePrefix("::ffff:0.0.0.0/96")))
+}
+
+func TestConsumeSpecialAddr(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{0x00, 0x00, 0x00, 0x01, 0x02})
+
+	assert.Equal(t, netip.MustParseAddr("127.0.0.1"),
+		fdp.ConsumeSpecialAddr())
+	assert.Equal(t, netip.IPv4Unspecified(), fdp.ConsumeSpecialAddr())
+}
+
+func TestConsumeSpecialAddrPort(t *testing.T) {
+	fdp := NewFuzzedDataProvider(
+		[]byte{0x00, 0x00, 0x00, 0x01, 0xbb, 0x01, 0x02})
+
+	assert.Equal(t, netip.MustParseAddrPort("127.0.0.1:443"),
+		fdp.ConsumeSpecialAddrPort())
+}
+
+func TestConsumeSpecialPrefix(t *testing.T) {
+	fdp := NewFuzzedDataProvider(
+		[]byte{0x00, 0x00, 0x00, 0x01, 0x08, 0x02})
+
+	assert.Equal(t, netip.MustParsePrefix("127.0.0.1/16"),
+		fdp.ConsumeSpecialPrefix())
+	assert.Equal(t, netip.MustParsePrefix("0.0.0.0/32"),
+		fdp.ConsumeSpecialPrefix())
+}
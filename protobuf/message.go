@@ -0,0 +1,350 @@
+package protobuf
+
+import (
+	"math"
+	"slices"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	fuzz "github.com/ngtcp2/fuzzeddataprovider-go"
+	"github.com/ngtcp2/fuzzeddataprovider-go/internal/gen"
+)
+
+const (
+	// maxUnknownFields is the maximum number of unknown fields in a
+	// message.
+	maxUnknownFields = 4
+	// maxVarintLen is the maximum length of varint which parsers
+	// accept.
+	maxVarintLen = 10
+	// numReserved is the number of field numbers reserved for the
+	// implementation.
+	numReserved = protowire.LastReservedNumber -
+		protowire.FirstReservedNumber + 1
+)
+
+// Options controls the messages generated by this package.  The zero
+// value generates well-formed messages without unknown fields with
+// default limits.
+type Options struct {
+	// MaxDepth is the maximum nesting depth of message and group
+	// fields.  Beyond MaxDepth, only required fields are generated,
+	// and nothing is generated beyond twice MaxDepth, so that a cycle
+	// of required fields terminates.  If it is 0, 8 is used.
+	MaxDepth int
+	// MaxRepeated is the maximum number of values of a repeated
+	// field.  If it is 0, 16 is used.
+	MaxRepeated int
+	// MaxDataLen is the maximum length of string and bytes values.  If
+	// it is 0, 256 is used.
+	MaxDataLen int
+	// UnknownFields allows fields whose numbers are not in the schema.
+	UnknownFields bool
+	// AllowIllegal allows output which parsers must reject, such as
+	// wire types which do not match the field, length prefixes which
+	// do not match the data, invalid UTF-8 in string values, varints
+	// longer than 10 bytes and unbalanced group tags.
+	AllowIllegal bool
+}
+
+type messageGen struct {
+	fdp         *fuzz.FuzzedDataProvider
+	opts        *Options
+	maxDepth    int
+	maxRepeated int
+	maxDataLen  int
+	// illegal is true if the field being generated may be malformed.
+	illegal bool
+}
+
+func newMessageGen(fdp *fuzz.FuzzedDataProvider, opts *Options) *messageGen {
+	opts = gen.OrZero(opts)
+
+	return &messageGen{
+		fdp:         fdp,
+		opts:        opts,
+		maxDepth:    gen.Limit(opts.MaxDepth, gen.DefaultMaxDepth),
+		maxRepeated: gen.Limit(opts.MaxRepeated, gen.DefaultMaxCount),
+		maxDataLen:  gen.Limit(opts.MaxDataLen, gen.DefaultMaxDataLen),
+	}
+}
+
+// ConsumeMessage returns the wire format encoding of message m
+// generated by consuming bytes from the input data.  The fields
+// present, their order, the number of values of repeated fields and
+// whether packed encoding is used are chosen by the input data.
+// Required fields are always present.  opts may be nil.  If there is
+// no input data left, it returns the message which contains only
+// required fields.
+func ConsumeMessage(
+	fdp *fuzz.FuzzedDataProvider, m *Message, opts *Options,
+) []byte {
+	return newMessageGen(fdp, opts).appendMessage(nil, m, 0)
+}
+
+func (g *messageGen) appendMessage(b []byte, m *Message, depth int) []byte {
+	if depth >= 2*g.maxDepth {
+		return b
+	}
+
+	fdp := g.fdp
+
+	var fields []Field
+
+	if depth < g.maxDepth {
+		fields = fuzz.ConsumeSubset(fdp, m.Fields)
+
+		if g.opts.UnknownFields {
+			// The zero Field stands for an unknown field.
+			for range maxUnknownFields {
+				if fdp.ConsumeUint8() == 0 {
+					break
+				}
+
+				fields = append(fields, Field{})
+			}
+		}
+	}
+
+	omitRequired := gen.ConsumeIllegal(fdp, g.opts.AllowIllegal)
+
+	for _, f := range m.Fields {
+		if f.Required && !omitRequired && !slices.ContainsFunc(fields,
+			func(sel Field) bool { return sel.Number == f.Number }) {
+			fields = append(fields, f)
+		}
+	}
+
+	fuzz.Shuffle(fdp, fields)
+
+	for _, f := range fields {
+		if f.Number == 0 {
+			b = g.appendUnknownField(b, m)
+
+			continue
+		}
+
+		b = g.appendField(b, &f, depth)
+	}
+
+	return b
+}
+
+func (g *messageGen) appendField(b []byte, f *Field, depth int) []byte {
+	fdp := g.fdp
+
+	g.illegal = gen.ConsumeIllegal(fdp, g.opts.AllowIllegal)
+
+	n := 1
+
+	if f.Repeated {
+		for n < g.maxRepeated && fdp.ConsumeUint8() != 0 {
+			n++
+		}
+
+		// Packed encoding is chosen with probability 3/4 if the field
+		// prefers it, and 1/4 otherwise.
+		if f.Kind.packable() &&
+			f.Packed != (fdp.ConsumeUint8InRange(0, 3) == 0) {
+			var values []byte
+
+			for range n {
+				values = g.appendValue(values, f, depth)
+			}
+
+			b = g.appendTag(b, f.Number, protowire.BytesType)
+
+			return g.appendLengthPrefixed(b, values)
+		}
+	}
+
+	for range n {
+		if f.Kind == KindGroup {
+			b = g.appendTag(b, f.Number, protowire.StartGroupType)
+			b = g.appendNested(b, f.Message, depth)
+
+			num := f.Number
+			if g.illegal && fdp.ConsumeBool() {
+				num = fdp.ConsumeInt32InRange(1, int32(protowire.MaxValidNumber))
+			}
+
+			b = protowire.AppendTag(b, protowire.Number(num),
+				protowire.EndGroupType)
+
+			continue
+		}
+
+		b = g.appendTag(b, f.Number, wireType(f.Kind))
+		b = g.appendValue(b, f, depth)
+	}
+
+	return b
+}
+
+// appendTag appends the tag of field number num and wire type typ to
+// b.  If g.illegal is true, the wire type might be replaced.
+func (g *messageGen) appendTag(
+	b []byte, num int32, typ protowire.Type,
+) []byte {
+	if g.illegal && g.fdp.ConsumeBool() {
+		typ = protowire.Type(g.fdp.ConsumeIntInRange(0, 7))
+	}
+
+	return g.appendVarint(b, protowire.EncodeTag(protowire.Number(num), typ))
+}
+
+// appendValue appends a value of field f without tag to b.
+func (g *messageGen) appendValue(b []byte, f *Field, depth int) []byte {
+	fdp := g.fdp
+
+	switch f.Kind {
+	case KindBool:
+		if g.illegal {
+			return g.appendVarint(b, fdp.ConsumeUint64())
+		}
+
+		return g.appendVarint(b, protowire.EncodeBool(fdp.ConsumeBool()))
+	case KindEnum:
+		v := fdp.ConsumeInt32()
+		if len(f.EnumValues) != 0 && !g.illegal {
+			v = f.EnumValues[fdp.ConsumeIntInRange(0, len(f.EnumValues)-1)]
+		}
+
+		return g.appendVarint(b, uint64(int64(v)))
+	case KindInt32:
+		return g.appendVarint(b, uint64(int64(fdp.ConsumeInt32())))
+	case KindSint32:
+		return g.appendVarint(b,
+			protowire.EncodeZigZag(int64(fdp.ConsumeInt32())))
+	case KindUint32:
+		return g.appendVarint(b, uint64(fdp.ConsumeUint32()))
+	case KindInt64, KindUint64:
+		return g.appendVarint(b, fdp.ConsumeUint64())
+	case KindSint64:
+		return g.appendVarint(b, protowire.EncodeZigZag(fdp.ConsumeInt64()))
+	case KindSfixed32, KindFixed32:
+		return protowire.AppendFixed32(b, fdp.ConsumeUint32())
+	case KindFloat:
+		return protowire.AppendFixed32(b, math.Float32bits(fdp.ConsumeFloat32()))
+	case KindSfixed64, KindFixed64:
+		return protowire.AppendFixed64(b, fdp.ConsumeUint64())
+	case KindDouble:
+		return protowire.AppendFixed64(b, math.Float64bits(fdp.ConsumeFloat64()))
+	case KindString:
+		s := fdp.ConsumeDictionaryString(g.maxDataLen)
+		if !g.illegal {
+			s = strings.ToValidUTF8(s, "\uFFFD")
+		}
+
+		return g.appendLengthPrefixed(b, []byte(s))
+	case KindBytes:
+		return g.appendLengthPrefixed(b,
+			fdp.ConsumeBytes(fdp.ConsumeIntInRange(0, g.maxDataLen)))
+	case KindMessage:
+		return g.appendLengthPrefixed(b, g.appendNested(nil, f.Message, depth))
+	default:
+		panic("unknown kind")
+	}
+}
+
+// appendNested appends the fields of nested message m to b.  It
+// preserves g.illegal which the fields of m overwrite.
+func (g *messageGen) appendNested(b []byte, m *Message, depth int) []byte {
+	illegal := g.illegal
+	b = g.appendMessage(b, m, depth+1)
+	g.illegal = illegal
+
+	return b
+}
+
+// appendUnknownField appends a field whose number is not in m to b.
+func (g *messageGen) appendUnknownField(b []byte, m *Message) []byte {
+	fdp := g.fdp
+
+	g.illegal = gen.ConsumeIllegal(fdp, g.opts.AllowIllegal)
+
+	// Skip the reserved range.
+	num := protowire.Number(fdp.ConsumeInt32InRange(1,
+		int32(protowire.MaxValidNumber-numReserved)))
+	if num >= protowire.FirstReservedNumber {
+		num += numReserved
+	}
+
+	if g.illegal && fdp.ConsumeBool() {
+		// Field number 0 and the reserved range are invalid.
+		num = 0
+		if fdp.ConsumeBool() {
+			num = protowire.Number(fdp.ConsumeInt32InRange(
+				int32(protowire.FirstReservedNumber),
+				int32(protowire.LastReservedNumber)))
+		}
+	}
+
+	if slices.ContainsFunc(m.Fields, func(f Field) bool {
+		return protowire.Number(f.Number) == num
+	}) && !g.illegal {
+		return b
+	}
+
+	switch fdp.ConsumeIntInRange(0, 3) {
+	case 0:
+		b = g.appendTag(b, int32(num), protowire.VarintType)
+
+		return g.appendVarint(b, fdp.ConsumeUint64())
+	case 1:
+		b = g.appendTag(b, int32(num), protowire.Fixed32Type)
+
+		return protowire.AppendFixed32(b, fdp.ConsumeUint32())
+	case 2:
+		b = g.appendTag(b, int32(num), protowire.Fixed64Type)
+
+		return protowire.AppendFixed64(b, fdp.ConsumeUint64())
+	default:
+		b = g.appendTag(b, int32(num), protowire.BytesType)
+
+		return g.appendLengthPrefixed(b,
+			fdp.ConsumeBytes(fdp.ConsumeIntInRange(0, g.maxDataLen)))
+	}
+}
+
+// appendVarint appends v as varint to b.  If g.illegal is true, the
+// encoding might be padded with redundant continuation bytes beyond
+// the 10 bytes limit.
+func (g *messageGen) appendVarint(b []byte, v uint64) []byte {
+	if !g.illegal || !g.fdp.ConsumeBool() {
+		return protowire.AppendVarint(b, v)
+	}
+
+	n := g.fdp.ConsumeIntInRange(maxVarintLen+1, 2*maxVarintLen)
+
+	for range n - 1 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+
+	return append(b, byte(v)&0x7f)
+}
+
+// appendLengthPrefixed appends data prefixed with its length to b.  If
+// g.illegal is true, the length might not match data.
+func (g *messageGen) appendLengthPrefixed(b, data []byte) []byte {
+	return gen.AppendLengthPrefixed(g.fdp, g.illegal, b, data, math.MaxUint64,
+		g.appendVarint)
+}
+
+// wireType returns the wire type of the field value of kind k.
+func wireType(k Kind) protowire.Type {
+	switch k {
+	case KindSfixed32, KindFixed32, KindFloat:
+		return protowire.Fixed32Type
+	case KindSfixed64, KindFixed64, KindDouble:
+		return protowire.Fixed64Type
+	case KindString, KindBytes, KindMessage:
+		return protowire.BytesType
+	case KindGroup:
+		return protowire.StartGroupType
+	default:
+		return protowire.VarintType
+	}
+}
@@ -0,0 +1,106 @@
+package protobuf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	fuzz "github.com/ngtcp2/fuzzeddataprovider-go"
+	"github.com/ngtcp2/fuzzeddataprovider-go/internal/gentest"
+)
+
+// validate checks that b is a well-formed sequence of fields.
+func validate(b []byte) error {
+	for len(b) != 0 {
+		_, _, n := protowire.ConsumeField(b)
+		if n < 0 {
+			return gentest.ErrMalformed
+		}
+
+		b = b[n:]
+	}
+
+	return nil
+}
+
+// groupMessage is a message which contains a repeated group of
+// itself.
+var groupMessage = func() *Message {
+	m := &Message{
+		Fields: []Field{
+			{Number: 1, Kind: KindSint64},
+			{Number: 2, Kind: KindString},
+			{Number: 3, Kind: KindFixed32, Repeated: true, Packed: true},
+		},
+	}
+
+	m.Fields = append(m.Fields, Field{
+		Number:   4,
+		Kind:     KindGroup,
+		Repeated: true,
+		Message:  m,
+	})
+
+	return m
+}()
+
+func TestConsumeMessage(t *testing.T) {
+	m := &Message{
+		Fields: []Field{
+			{Number: 1, Kind: KindBool},
+			{Number: 2, Kind: KindUint32, Required: true},
+		},
+	}
+
+	fdp := fuzz.NewFuzzedDataProvider(nil)
+
+	assert.Equal(t, []byte{0x10, 0x00}, ConsumeMessage(fdp, m, nil))
+
+	// The subset mask 0b01 selects field 1, and the shuffle keeps the
+	// order.  The value of field 1 is true, and then field 2 gets 0.
+	fdp = fuzz.NewFuzzedDataProvider([]byte{0x01, 0x01, 0x01})
+
+	assert.Equal(t, []byte{0x08, 0x01, 0x10, 0x00},
+		ConsumeMessage(fdp, m, nil))
+}
+
+// unmarshaler returns the function which unmarshals b into a new
+// message of the same type as msg.
+func unmarshaler(msg proto.Message) func(b []byte) error {
+	return func(b []byte) error {
+		return proto.Unmarshal(b, msg.ProtoReflect().New().Interface())
+	}
+}
+
+func TestConsumeMessageProperty(t *testing.T) {
+	for _, tc := range []struct {
+		m     *Message
+		parse func(b []byte) error
+	}{
+		{
+			m:     FromDescriptor((&structpb.Value{}).ProtoReflect().Descriptor()),
+			parse: unmarshaler(&structpb.Value{}),
+		},
+		{
+			m: FromDescriptor(
+				(&descriptorpb.FileDescriptorProto{}).ProtoReflect().Descriptor()),
+			parse: unmarshaler(&descriptorpb.FileDescriptorProto{}),
+		},
+		{
+			m:     groupMessage,
+			parse: validate,
+		},
+	} {
+		gentest.Check(t, func(data []byte, illegal bool) []byte {
+			return ConsumeMessage(fuzz.NewFuzzedDataProvider(data), tc.m,
+				&Options{
+					UnknownFields: true,
+					AllowIllegal:  illegal,
+				})
+		}, tc.parse)
+	}
+}
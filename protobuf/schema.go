@@ -0,0 +1,160 @@
+// Package protobuf provides helpers which generate Protocol Buffers
+// wire format messages from FuzzedDataProvider.
+package protobuf
+
+import "google.golang.org/protobuf/reflect/protoreflect"
+
+// Kind is the type of field value.
+type Kind int
+
+// Kinds of field value, which correspond to protoreflect.Kind.
+const (
+	KindBool Kind = iota + 1
+	KindEnum
+	KindInt32
+	KindSint32
+	KindUint32
+	KindInt64
+	KindSint64
+	KindUint64
+	KindSfixed32
+	KindFixed32
+	KindFloat
+	KindSfixed64
+	KindFixed64
+	KindDouble
+	KindString
+	KindBytes
+	KindMessage
+	KindGroup
+)
+
+// Field describes a field of Message.
+type Field struct {
+	// Number is the field number.
+	Number int32
+	// Kind is the type of field value.
+	Kind Kind
+	// Repeated is true if the field is repeated.
+	Repeated bool
+	// Required is true if the field must be present.  It is only
+	// meaningful in proto2.
+	Required bool
+	// Packed is true if the repeated scalar field prefers packed
+	// encoding.  Parsers must accept both encodings, and the provider
+	// chooses the encoding regardless of Packed, but the preferred
+	// one is chosen more often.
+	Packed bool
+	// EnumValues is the values of enum for KindEnum.  If it is empty,
+	// any int32 value is generated.
+	EnumValues []int32
+	// Message is the type of field value for KindMessage and
+	// KindGroup.
+	Message *Message
+}
+
+// Message describes a message type.  It can be built by hand, or
+// converted from protoreflect.MessageDescriptor by FromDescriptor.  A
+// Message may refer to itself through Field.Message.
+type Message struct {
+	Fields []Field
+}
+
+// FromDescriptor returns Message converted from md.  Extensions are
+// not included.
+func FromDescriptor(md protoreflect.MessageDescriptor) *Message {
+	return fromDescriptor(md, make(map[protoreflect.FullName]*Message))
+}
+
+func fromDescriptor(
+	md protoreflect.MessageDescriptor,
+	seen map[protoreflect.FullName]*Message,
+) *Message {
+	if m, ok := seen[md.FullName()]; ok {
+		return m
+	}
+
+	m := &Message{}
+	seen[md.FullName()] = m
+
+	fds := md.Fields()
+	for i := range fds.Len() {
+		fd := fds.Get(i)
+
+		f := Field{
+			Number:   int32(fd.Number()),
+			Kind:     kindOf(fd.Kind()),
+			Repeated: fd.IsList() || fd.IsMap(),
+			Required: fd.Cardinality() == protoreflect.Required,
+			Packed:   fd.IsPacked(),
+		}
+
+		switch f.Kind {
+		case KindEnum:
+			values := fd.Enum().Values()
+			for j := range values.Len() {
+				f.EnumValues = append(f.EnumValues, int32(values.Get(j).Number()))
+			}
+		case KindMessage, KindGroup:
+			f.Message = fromDescriptor(fd.Message(), seen)
+		}
+
+		m.Fields = append(m.Fields, f)
+	}
+
+	return m
+}
+
+func kindOf(k protoreflect.Kind) Kind {
+	switch k {
+	case protoreflect.BoolKind:
+		return KindBool
+	case protoreflect.EnumKind:
+		return KindEnum
+	case protoreflect.Int32Kind:
+		return KindInt32
+	case protoreflect.Sint32Kind:
+		return KindSint32
+	case protoreflect.Uint32Kind:
+		return KindUint32
+	case protoreflect.Int64Kind:
+		return KindInt64
+	case protoreflect.Sint64Kind:
+		return KindSint64
+	case protoreflect.Uint64Kind:
+		return KindUint64
+	case protoreflect.Sfixed32Kind:
+		return KindSfixed32
+	case protoreflect.Fixed32Kind:
+		return KindFixed32
+	case protoreflect.FloatKind:
+		return KindFloat
+	case protoreflect.Sfixed64Kind:
+		return KindSfixed64
+	case protoreflect.Fixed64Kind:
+		return KindFixed64
+	case protoreflect.DoubleKind:
+		return KindDouble
+	case protoreflect.StringKind:
+		return KindString
+	case protoreflect.BytesKind:
+		return KindBytes
+	case protoreflect.MessageKind:
+		return KindMessage
+	case protoreflect.GroupKind:
+		return KindGroup
+	default:
+		panic("unknown kind")
+	}
+}
+
+// packable returns true if the field of kind k can use packed
+// encoding.
+func (k Kind) packable() bool {
+	switch k {
+	case KindString, KindBytes, KindMessage, KindGroup:
+		return false
+	default:
+		return true
+	}
+}
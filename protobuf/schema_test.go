@@ -0,0 +1,56 @@
+package protobuf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestFromDescriptor(t *testing.T) {
+	m := FromDescriptor((&structpb.Value{}).ProtoReflect().Descriptor())
+
+	require.Len(t, m.Fields, 6)
+	assert.Equal(t, Field{
+		Number:     1,
+		Kind:       KindEnum,
+		EnumValues: []int32{0},
+	}, m.Fields[0])
+	assert.Equal(t, KindDouble, m.Fields[1].Kind)
+	assert.Equal(t, KindString, m.Fields[2].Kind)
+	assert.Equal(t, KindBool, m.Fields[3].Kind)
+
+	// Struct.fields is map<string, Value>, whose entry refers back to
+	// Value.
+	s := m.Fields[4].Message
+	require.Len(t, s.Fields, 1)
+	assert.True(t, s.Fields[0].Repeated)
+
+	entry := s.Fields[0].Message
+	require.Len(t, entry.Fields, 2)
+	assert.Equal(t, KindString, entry.Fields[0].Kind)
+	assert.Same(t, m, entry.Fields[1].Message)
+}
+
+func TestFromDescriptorProto2(t *testing.T) {
+	m := FromDescriptor(
+		(&descriptorpb.UninterpretedOption_NamePart{}).ProtoReflect().
+			Descriptor())
+
+	assert.Equal(t, []Field{
+		{Number: 1, Kind: KindString, Required: true},
+		{Number: 2, Kind: KindBool, Required: true},
+	}, m.Fields)
+
+	m = FromDescriptor(
+		(&descriptorpb.SourceCodeInfo_Location{}).ProtoReflect().Descriptor())
+
+	assert.Equal(t, Field{
+		Number:   1,
+		Kind:     KindInt32,
+		Repeated: true,
+		Packed:   true,
+	}, m.Fields[0])
+}
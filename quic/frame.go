@@ -0,0 +1,383 @@
+package quic
+
+import (
+	fuzz "github.com/ngtcp2/fuzzeddataprovider-go"
+	"github.com/ngtcp2/fuzzeddataprovider-go/internal/gen"
+)
+
+// Frame types defined in RFC 9000 and RFC 9221.
+const (
+	FrameTypePadding            = 0x00
+	FrameTypePing               = 0x01
+	FrameTypeAck                = 0x02
+	FrameTypeAckECN             = 0x03
+	FrameTypeResetStream        = 0x04
+	FrameTypeStopSending        = 0x05
+	FrameTypeCrypto             = 0x06
+	FrameTypeNewToken           = 0x07
+	FrameTypeStream             = 0x08
+	FrameTypeMaxData            = 0x10
+	FrameTypeMaxStreamData      = 0x11
+	FrameTypeMaxStreamsBidi     = 0x12
+	FrameTypeMaxStreamsUni      = 0x13
+	FrameTypeDataBlocked        = 0x14
+	FrameTypeStreamDataBlocked  = 0x15
+	FrameTypeStreamsBlockedBidi = 0x16
+	FrameTypeStreamsBlockedUni  = 0x17
+	FrameTypeNewConnectionID    = 0x18
+	FrameTypeRetireConnectionID = 0x19
+	FrameTypePathChallenge      = 0x1a
+	FrameTypePathResponse       = 0x1b
+	FrameTypeConnectionClose    = 0x1c
+	FrameTypeConnectionCloseApp = 0x1d
+	FrameTypeHandshakeDone      = 0x1e
+	FrameTypeDatagram           = 0x30
+	FrameTypeDatagramLen        = 0x31
+)
+
+// Flags of STREAM frame type.
+const (
+	StreamFlagFin = 0x01
+	StreamFlagLen = 0x02
+	StreamFlagOff = 0x04
+)
+
+const (
+	// maxStreams is the maximum value of MAX_STREAMS and
+	// STREAMS_BLOCKED frames.
+	maxStreams = 1 << 60
+	// maxAckRanges is the maximum number of ACK Ranges in ACK frame.
+	maxAckRanges = 16
+	// statelessResetTokenLen is the length of Stateless Reset Token.
+	statelessResetTokenLen = 16
+	// pathDataLen is the length of PATH_CHALLENGE and PATH_RESPONSE
+	// data.
+	pathDataLen = 8
+)
+
+var frameTypes = []uint64{
+	FrameTypePadding,
+	FrameTypePing,
+	FrameTypeAck,
+	FrameTypeAckECN,
+	FrameTypeResetStream,
+	FrameTypeStopSending,
+	FrameTypeCrypto,
+	FrameTypeNewToken,
+	FrameTypeStream,
+	FrameTypeMaxData,
+	FrameTypeMaxStreamData,
+	FrameTypeMaxStreamsBidi,
+	FrameTypeMaxStreamsUni,
+	FrameTypeDataBlocked,
+	FrameTypeStreamDataBlocked,
+	FrameTypeStreamsBlockedBidi,
+	FrameTypeStreamsBlockedUni,
+	FrameTypeNewConnectionID,
+	FrameTypeRetireConnectionID,
+	FrameTypePathChallenge,
+	FrameTypePathResponse,
+	FrameTypeConnectionClose,
+	FrameTypeConnectionCloseApp,
+	FrameTypeHandshakeDone,
+	FrameTypeDatagram,
+	FrameTypeDatagramLen,
+}
+
+// FrameOptions controls the frames generated by ConsumeFrame and
+// ConsumeFrames.  The zero value generates any frame type defined in
+// RFC 9000 and RFC 9221 with default limits.
+type FrameOptions struct {
+	// Types is the frame types to generate.  FrameTypeStream stands
+	// for all STREAM frame types, whose flags are chosen by consuming
+	// bytes from the input data.  If Types is empty, all frame types
+	// are generated.
+	Types []uint64
+	// MaxFrames is the maximum number of frames ConsumeFrames
+	// generates.  If it is 0, 16 is used.
+	MaxFrames int
+	// MaxDataLen is the maximum length of variable length data, such
+	// as stream data, tokens and reason phrases.  If it is 0, 256 is
+	// used.
+	MaxDataLen int
+	// AllowIllegal allows frames which violate RFC 9000, such as
+	// unknown frame types, non-minimal encoding of frame types,
+	// length fields which do not match data, ACK ranges which go
+	// below 0, STREAM frames which exceed the maximum offset, and
+	// connection IDs of invalid length.
+	AllowIllegal bool
+}
+
+type frameGen struct {
+	fdp          *fuzz.FuzzedDataProvider
+	types        []uint64
+	maxFrames    int
+	maxDataLen   int
+	allowIllegal bool
+	// illegal is true if the frame being generated may violate RFC
+	// 9000.
+	illegal bool
+}
+
+func newFrameGen(fdp *fuzz.FuzzedDataProvider, opts *FrameOptions) *frameGen {
+	opts = gen.OrZero(opts)
+
+	g := &frameGen{
+		fdp:          fdp,
+		types:        frameTypes,
+		maxFrames:    gen.Limit(opts.MaxFrames, gen.DefaultMaxCount),
+		maxDataLen:   gen.Limit(opts.MaxDataLen, gen.DefaultMaxDataLen),
+		allowIllegal: opts.AllowIllegal,
+	}
+
+	if len(opts.Types) != 0 {
+		g.types = opts.Types
+	}
+
+	return g
+}
+
+// ConsumeFrame returns an encoded QUIC frame generated by consuming
+// bytes from the input data.  opts may be nil.  If there is no input
+// data left, it returns a PADDING frame unless opts.Types says
+// otherwise.
+func ConsumeFrame(fdp *fuzz.FuzzedDataProvider, opts *FrameOptions) []byte {
+	b, _ := newFrameGen(fdp, opts).appendFrame(nil)
+
+	return b
+}
+
+// ConsumeFrames returns a sequence of encoded QUIC frames generated by
+// consuming bytes from the input data.  The number of frames is chosen
+// in the same way as fuzz.ConsumeSlice.  A STREAM or DATAGRAM frame
+// without Length field extends to the end of packet, so that it is
+// always the last frame.  opts may be nil.
+func ConsumeFrames(fdp *fuzz.FuzzedDataProvider, opts *FrameOptions) []byte {
+	g := newFrameGen(fdp, opts)
+
+	var b []byte
+
+	for range g.maxFrames {
+		if fdp.ConsumeUint8() == 0 {
+			break
+		}
+
+		var last bool
+
+		b, last = g.appendFrame(b)
+		if last {
+			break
+		}
+	}
+
+	return b
+}
+
+// appendFrame appends a frame to b.  It returns true if the frame
+// extends to the end of packet.
+func (g *frameGen) appendFrame(b []byte) ([]byte, bool) {
+	fdp := g.fdp
+
+	g.illegal = gen.ConsumeIllegal(fdp, g.allowIllegal)
+
+	typ := g.types[fdp.ConsumeIntInRange(0, len(g.types)-1)]
+	if typ == FrameTypeStream {
+		typ |= fdp.ConsumeUint64InRange(0, 7)
+	}
+
+	if g.illegal && fdp.ConsumeBool() {
+		typ = ConsumeVarint(fdp)
+	}
+
+	if g.illegal {
+		b = AppendConsumedVarint(b, fdp, typ)
+	} else {
+		b = AppendVarint(b, typ)
+	}
+
+	if typ&^0x07 == FrameTypeStream {
+		return g.appendStream(b, typ)
+	}
+
+	switch typ {
+	case FrameTypePadding, FrameTypePing, FrameTypeHandshakeDone:
+		// No fields.
+	case FrameTypeAck, FrameTypeAckECN:
+		b = g.appendAck(b, typ == FrameTypeAckECN)
+	case FrameTypeResetStream:
+		b = g.appendVarint(b)
+		b = g.appendVarint(b)
+		b = g.appendVarint(b)
+	case FrameTypeStopSending:
+		b = g.appendVarint(b)
+		b = g.appendVarint(b)
+	case FrameTypeCrypto:
+		data := g.consumeData()
+		b = g.appendVarintMax(b, MaxVarint-uint64(len(data)))
+		b = appendLengthPrefixed(g.fdp, g.illegal, b, data)
+	case FrameTypeNewToken:
+		data := g.consumeData()
+		if len(data) == 0 && !g.illegal {
+			data = []byte{0}
+		}
+
+		b = appendLengthPrefixed(g.fdp, g.illegal, b, data)
+	case FrameTypeMaxData, FrameTypeDataBlocked,
+		FrameTypeRetireConnectionID:
+		b = g.appendVarint(b)
+	case FrameTypeMaxStreamData, FrameTypeStreamDataBlocked:
+		b = g.appendVarint(b)
+		b = g.appendVarint(b)
+	case FrameTypeMaxStreamsBidi, FrameTypeMaxStreamsUni,
+		FrameTypeStreamsBlockedBidi, FrameTypeStreamsBlockedUni:
+		b = g.appendVarintMax(b, maxStreams)
+	case FrameTypeNewConnectionID:
+		b = g.appendNewConnectionID(b)
+	case FrameTypePathChallenge, FrameTypePathResponse:
+		b = gen.AppendZeroPadded(b, g.fdp, pathDataLen)
+	case FrameTypeConnectionClose:
+		b = g.appendVarint(b)
+		b = g.appendVarint(b)
+		b = appendLengthPrefixed(g.fdp, g.illegal, b, g.consumeData())
+	case FrameTypeConnectionCloseApp:
+		b = g.appendVarint(b)
+		b = appendLengthPrefixed(g.fdp, g.illegal, b, g.consumeData())
+	case FrameTypeDatagram:
+		return append(b, g.consumeData()...), true
+	case FrameTypeDatagramLen:
+		b = appendLengthPrefixed(g.fdp, g.illegal, b, g.consumeData())
+	default:
+		// Unknown frame type.  Append some bytes so that the parser
+		// has something to choke on.
+		return append(b, g.consumeData()...), true
+	}
+
+	return b, false
+}
+
+// appendVarint appends an arbitrary variable-length integer to b.
+func (g *frameGen) appendVarint(b []byte) []byte {
+	return AppendConsumedVarint(b, g.fdp, ConsumeVarint(g.fdp))
+}
+
+// appendVarintMax appends a variable-length integer in the range [0,
+// maxVal] to b.  If g.illegal is true, the value might exceed maxVal.
+func (g *frameGen) appendVarintMax(b []byte, maxVal uint64) []byte {
+	return AppendConsumedVarint(b, g.fdp, g.consumeVarintMax(maxVal))
+}
+
+func (g *frameGen) consumeVarintMax(maxVal uint64) uint64 {
+	v := ConsumeVarint(g.fdp)
+	if v > maxVal && !g.illegal {
+		v %= maxVal + 1
+	}
+
+	return v
+}
+
+func (g *frameGen) consumeData() []byte {
+	return g.fdp.ConsumeBytes(g.fdp.ConsumeIntInRange(0, g.maxDataLen))
+}
+
+// appendLengthPrefixed appends the length of data as variable-length
+// integer and data to b.  If illegal is true, the length might not
+// match data.
+func appendLengthPrefixed(
+	fdp *fuzz.FuzzedDataProvider, illegal bool, b, data []byte,
+) []byte {
+	return gen.AppendLengthPrefixed(fdp, illegal, b, data, MaxVarint,
+		func(b []byte, n uint64) []byte {
+			return AppendConsumedVarint(b, fdp, n)
+		})
+}
+
+func (g *frameGen) appendAck(b []byte, ecn bool) []byte {
+	fdp := g.fdp
+
+	largest := ConsumeVarint(fdp)
+	first := g.consumeVarintMax(largest)
+
+	var ranges []uint64
+
+	if g.illegal {
+		ranges = fuzz.ConsumeSlice(fdp, 2*maxAckRanges, ConsumeVarint)
+	} else {
+		smallest := largest - first
+
+		for range maxAckRanges {
+			if smallest < 2 || fdp.ConsumeUint8() == 0 {
+				break
+			}
+
+			gap := fdp.ConsumeUint64InRange(0, smallest-2)
+			next := smallest - gap - 2
+			ackRange := fdp.ConsumeUint64InRange(0, next)
+			smallest = next - ackRange
+
+			ranges = append(ranges, gap, ackRange)
+		}
+	}
+
+	b = AppendConsumedVarint(b, fdp, largest)
+	// ACK Delay
+	b = g.appendVarint(b)
+
+	rangeCount := uint64(len(ranges) / 2)
+	if g.illegal && fdp.ConsumeBool() {
+		rangeCount = ConsumeVarint(fdp)
+	}
+
+	b = AppendConsumedVarint(b, fdp, rangeCount)
+	b = AppendConsumedVarint(b, fdp, first)
+
+	for _, v := range ranges {
+		b = AppendConsumedVarint(b, fdp, v)
+	}
+
+	if ecn {
+		// ECT0, ECT1 and ECN-CE counts
+		b = g.appendVarint(b)
+		b = g.appendVarint(b)
+		b = g.appendVarint(b)
+	}
+
+	return b
+}
+
+func (g *frameGen) appendStream(b []byte, typ uint64) ([]byte, bool) {
+	// Stream ID
+	b = g.appendVarint(b)
+
+	data := g.consumeData()
+
+	if typ&StreamFlagOff != 0 {
+		b = g.appendVarintMax(b, MaxVarint-uint64(len(data)))
+	}
+
+	if typ&StreamFlagLen == 0 {
+		return append(b, data...), true
+	}
+
+	return appendLengthPrefixed(g.fdp, g.illegal, b, data), false
+}
+
+func (g *frameGen) appendNewConnectionID(b []byte) []byte {
+	fdp := g.fdp
+
+	seq := ConsumeVarint(fdp)
+	b = AppendConsumedVarint(b, fdp, seq)
+	b = g.appendVarintMax(b, seq)
+
+	var cidlen int
+
+	if g.illegal {
+		cidlen = int(fdp.ConsumeUint8())
+	} else {
+		cidlen = fdp.ConsumeIntInRange(1, MaxConnectionIDLen)
+	}
+
+	b = append(b, byte(cidlen))
+	b = gen.AppendZeroPadded(b, g.fdp, cidlen)
+
+	return gen.AppendZeroPadded(b, g.fdp, statelessResetTokenLen)
+}
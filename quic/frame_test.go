@@ -0,0 +1,191 @@
+package quic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	fuzz "github.com/ngtcp2/fuzzeddataprovider-go"
+	"github.com/ngtcp2/fuzzeddataprovider-go/internal/gentest"
+)
+
+type frameReader struct {
+	gentest.Reader
+}
+
+func newFrameReader(b []byte) *frameReader {
+	return &frameReader{gentest.Reader{B: b}}
+}
+
+func (r *frameReader) varint() uint64 {
+	n := 1 << (r.Peek() >> 6)
+	v := r.Uint(n)
+
+	return v & (1<<(8*n-2) - 1)
+}
+
+// parseFrames validates the frames in b according to RFC 9000.
+func parseFrames(b []byte) error {
+	return gentest.Parse(func() { newFrameReader(b).frames() })
+}
+
+func (r *frameReader) frames() {
+	for len(r.B) != 0 {
+		typ := r.varint()
+
+		switch {
+		case typ == FrameTypePadding, typ == FrameTypePing,
+			typ == FrameTypeHandshakeDone:
+		case typ == FrameTypeAck, typ == FrameTypeAckECN:
+			largest := r.varint()
+			r.varint()
+			count := r.varint()
+			first := r.varint()
+			r.Check(first <= largest)
+
+			smallest := largest - first
+
+			for range count {
+				gap := r.varint()
+				r.Check(gap+2 <= smallest)
+
+				next := smallest - gap - 2
+				ackRange := r.varint()
+				r.Check(ackRange <= next)
+
+				smallest = next - ackRange
+			}
+
+			if typ == FrameTypeAckECN {
+				r.varint()
+				r.varint()
+				r.varint()
+			}
+		case typ == FrameTypeResetStream:
+			r.varint()
+			r.varint()
+			r.varint()
+		case typ == FrameTypeStopSending, typ == FrameTypeMaxStreamData,
+			typ == FrameTypeStreamDataBlocked:
+			r.varint()
+			r.varint()
+		case typ == FrameTypeCrypto:
+			off := r.varint()
+			n := r.varint()
+			r.Check(off+n <= MaxVarint)
+			r.Bytes(n)
+		case typ == FrameTypeNewToken:
+			n := r.varint()
+			r.Check(n > 0)
+			r.Bytes(n)
+		case typ&^0x07 == FrameTypeStream:
+			r.varint()
+
+			var off uint64
+
+			if typ&StreamFlagOff != 0 {
+				off = r.varint()
+			}
+
+			if typ&StreamFlagLen == 0 {
+				r.Check(off+uint64(len(r.B)) <= MaxVarint)
+
+				return
+			}
+
+			n := r.varint()
+			r.Check(off+n <= MaxVarint)
+			r.Bytes(n)
+		case typ == FrameTypeMaxData, typ == FrameTypeDataBlocked,
+			typ == FrameTypeRetireConnectionID:
+			r.varint()
+		case typ >= FrameTypeMaxStreamsBidi &&
+			typ <= FrameTypeStreamsBlockedUni:
+			r.Check(r.varint() <= maxStreams)
+		case typ == FrameTypeNewConnectionID:
+			seq := r.varint()
+			r.Check(r.varint() <= seq)
+
+			n := r.Bytes(1)[0]
+			r.Check(n >= 1 && n <= MaxConnectionIDLen)
+			r.Bytes(uint64(n) + statelessResetTokenLen)
+		case typ == FrameTypePathChallenge, typ == FrameTypePathResponse:
+			r.Bytes(pathDataLen)
+		case typ == FrameTypeConnectionClose:
+			r.varint()
+			r.varint()
+			r.Bytes(r.varint())
+		case typ == FrameTypeConnectionCloseApp:
+			r.varint()
+			r.Bytes(r.varint())
+		case typ == FrameTypeDatagram:
+			return
+		case typ == FrameTypeDatagramLen:
+			r.Bytes(r.varint())
+		default:
+			r.Check(false)
+		}
+	}
+}
+
+func TestConsumeFrame(t *testing.T) {
+	fdp := fuzz.NewFuzzedDataProvider(nil)
+
+	assert.Equal(t, []byte{FrameTypePadding}, ConsumeFrame(fdp, nil))
+	assert.Equal(t, []byte{FrameTypePing}, ConsumeFrame(fdp, &FrameOptions{
+		Types: []uint64{FrameTypePing},
+	}))
+	assert.Equal(t, []byte{
+		FrameTypeNewConnectionID, 0x00, 0x00, 0x01, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}, ConsumeFrame(fdp, &FrameOptions{
+		Types: []uint64{FrameTypeNewConnectionID},
+	}))
+
+	fdp = fuzz.NewFuzzedDataProvider([]byte{
+		'f', 'o', 'o', 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x07,
+	})
+
+	assert.Equal(t, []byte{
+		FrameTypeStream | StreamFlagOff | StreamFlagLen | StreamFlagFin,
+		0x00, 0x00, 0x03, 'f', 'o', 'o',
+	}, ConsumeFrame(fdp, &FrameOptions{
+		Types:      []uint64{FrameTypeStream},
+		MaxDataLen: 3,
+	}))
+}
+
+func TestConsumeFrames(t *testing.T) {
+	fdp := fuzz.NewFuzzedDataProvider([]byte{0x00, 0x01, 0x01})
+
+	assert.Equal(t, []byte{FrameTypePing, FrameTypePing},
+		ConsumeFrames(fdp, &FrameOptions{
+			Types: []uint64{FrameTypePing},
+		}))
+
+	fdp = fuzz.NewFuzzedDataProvider([]byte{0x01, 0x01, 0x01, 0x01})
+
+	assert.Equal(t, []byte{FrameTypePing, FrameTypePing},
+		ConsumeFrames(fdp, &FrameOptions{
+			Types:     []uint64{FrameTypePing},
+			MaxFrames: 2,
+		}))
+
+	fdp = fuzz.NewFuzzedDataProvider(
+		[]byte{'f', 'o', 'o', 0x03, 0x00, 0x01})
+
+	assert.Equal(t, []byte{FrameTypeDatagram, 'f', 'o', 'o'},
+		ConsumeFrames(fdp, &FrameOptions{
+			Types:      []uint64{FrameTypeDatagram, FrameTypePing},
+			MaxDataLen: 3,
+		}))
+}
+
+func TestConsumeFramesProperty(t *testing.T) {
+	gentest.Check(t, func(data []byte, illegal bool) []byte {
+		return ConsumeFrames(fuzz.NewFuzzedDataProvider(data), &FrameOptions{
+			AllowIllegal: illegal,
+		})
+	}, parseFrames)
+}
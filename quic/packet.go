@@ -0,0 +1,70 @@
+package quic
+
+import (
+	"math/bits"
+
+	fuzz "github.com/ngtcp2/fuzzeddataprovider-go"
+)
+
+const (
+	// MaxConnectionIDLen is the maximum length of QUIC v1 connection
+	// ID.
+	MaxConnectionIDLen = 20
+	// MaxPacketNumber is the maximum packet number.
+	MaxPacketNumber = 1<<62 - 1
+)
+
+// ConsumeConnectionID returns a connection ID of length from 0 to
+// MaxConnectionIDLen by consuming bytes from the input data.  If fewer
+// bytes than the chosen length remain, the rest is filled with zeros.
+// If there is no input data left, it returns an empty connection ID.
+func ConsumeConnectionID(fdp *fuzz.FuzzedDataProvider) []byte {
+	cid := make([]byte, fdp.ConsumeIntInRange(0, MaxConnectionIDLen))
+	copy(cid, fdp.ConsumeBytes(len(cid)))
+
+	return cid
+}
+
+// ConsumePacketNumber returns a packet number in the range [0,
+// MaxPacketNumber] by consuming bytes from the input data.  Like
+// ConsumeVarint, small and large packet numbers are equally likely.
+// If there is no input data left, it always returns 0.
+func ConsumePacketNumber(fdp *fuzz.FuzzedDataProvider) int64 {
+	return int64(ConsumeVarint(fdp))
+}
+
+// PacketNumberLen returns the minimum number of bytes required to
+// encode pn as described in RFC 9000 Appendix A.2.  largestAcked is
+// the largest acknowledged packet number, or -1 if no packet has been
+// acknowledged.  It returns 4 if pn cannot be encoded.
+func PacketNumberLen(pn, largestAcked int64) int {
+	numUnacked := max(pn-largestAcked, 1)
+
+	return min((bits.Len64(uint64(numUnacked))+1+7)/8, 4)
+}
+
+// AppendPacketNumber appends the least significant n bytes of pn to b.
+// n must be in the range [1, 4].
+func AppendPacketNumber(b []byte, pn int64, n int) []byte {
+	if n < 1 || n > 4 {
+		panic("n must be in the range [1, 4]")
+	}
+
+	for i := n - 1; i >= 0; i-- {
+		b = append(b, byte(pn>>(i*8)))
+	}
+
+	return b
+}
+
+// ConsumePacketNumberEncoding returns pn truncated to the length
+// chosen by consuming bytes from the input data.  The length is in the
+// range [PacketNumberLen(pn, largestAcked), 4].  If there is no input
+// data left, it returns the shortest encoding.
+func ConsumePacketNumberEncoding(
+	fdp *fuzz.FuzzedDataProvider, pn, largestAcked int64,
+) []byte {
+	n := fdp.ConsumeIntInRange(PacketNumberLen(pn, largestAcked), 4)
+
+	return AppendPacketNumber(nil, pn, n)
+}
@@ -0,0 +1,51 @@
+package quic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	fuzz "github.com/ngtcp2/fuzzeddataprovider-go"
+)
+
+func TestConsumeConnectionID(t *testing.T) {
+	fdp := fuzz.NewFuzzedDataProvider(
+		[]byte{0xde, 0xad, 0xbe, 0xef, 0x01, 0x04})
+
+	assert.Equal(t, []byte{0xde, 0xad, 0xbe, 0xef}, ConsumeConnectionID(fdp))
+	assert.Equal(t, make([]byte, 1), ConsumeConnectionID(fdp))
+	assert.Empty(t, ConsumeConnectionID(fdp))
+}
+
+func TestPacketNumberLen(t *testing.T) {
+	// Examples from RFC 9000 Appendix A.2.
+	assert.Equal(t, 2, PacketNumberLen(0xac5c02, 0xabe8b3))
+	assert.Equal(t, 3, PacketNumberLen(0xace8fe, 0xabe8b3))
+	assert.Equal(t, 1, PacketNumberLen(0, -1))
+	assert.Equal(t, 1, PacketNumberLen(0, 10))
+	assert.Equal(t, 4, PacketNumberLen(MaxPacketNumber, -1))
+}
+
+func TestAppendPacketNumber(t *testing.T) {
+	assert.Equal(t, []byte{0x5c, 0x02}, AppendPacketNumber(nil, 0xac5c02, 2))
+	assert.Equal(t, []byte{0x00, 0xac, 0x5c, 0x02},
+		AppendPacketNumber(nil, 0xac5c02, 4))
+
+	assert.Panics(t, func() { AppendPacketNumber(nil, 0, 5) })
+}
+
+func TestConsumePacketNumber(t *testing.T) {
+	fdp := fuzz.NewFuzzedDataProvider([]byte{0x07, 0x00})
+
+	assert.Equal(t, int64(7), ConsumePacketNumber(fdp))
+	assert.Equal(t, int64(0), ConsumePacketNumber(fdp))
+}
+
+func TestConsumePacketNumberEncoding(t *testing.T) {
+	fdp := fuzz.NewFuzzedDataProvider([]byte{0x02})
+
+	assert.Equal(t, []byte{0x00, 0xac, 0x5c, 0x02},
+		ConsumePacketNumberEncoding(fdp, 0xac5c02, 0xabe8b3))
+	assert.Equal(t, []byte{0x5c, 0x02},
+		ConsumePacketNumberEncoding(fdp, 0xac5c02, 0xabe8b3))
+}
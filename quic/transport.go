@@ -0,0 +1,267 @@
+package quic
+
+import (
+	"slices"
+
+	fuzz "github.com/ngtcp2/fuzzeddataprovider-go"
+	"github.com/ngtcp2/fuzzeddataprovider-go/internal/gen"
+)
+
+// Transport parameter IDs defined in RFC 9000, RFC 9221 and RFC 9368.
+const (
+	TransportParamOriginalDestinationConnectionID = 0x00
+	TransportParamMaxIdleTimeout                  = 0x01
+	TransportParamStatelessResetToken             = 0x02
+	TransportParamMaxUDPPayloadSize               = 0x03
+	TransportParamInitialMaxData                  = 0x04
+	TransportParamInitialMaxStreamDataBidiLocal   = 0x05
+	TransportParamInitialMaxStreamDataBidiRemote  = 0x06
+	TransportParamInitialMaxStreamDataUni         = 0x07
+	TransportParamInitialMaxStreamsBidi           = 0x08
+	TransportParamInitialMaxStreamsUni            = 0x09
+	TransportParamAckDelayExponent                = 0x0a
+	TransportParamMaxAckDelay                     = 0x0b
+	TransportParamDisableActiveMigration          = 0x0c
+	TransportParamPreferredAddress                = 0x0d
+	TransportParamActiveConnectionIDLimit         = 0x0e
+	TransportParamInitialSourceConnectionID       = 0x0f
+	TransportParamRetrySourceConnectionID         = 0x10
+	TransportParamVersionInformation              = 0x11
+	TransportParamMaxDatagramFrameSize            = 0x20
+	// TransportParamReserved is the smallest reserved transport
+	// parameter ID of the form 31 * N + 27.
+	TransportParamReserved = 0x1b
+)
+
+// Version1 and Version2 are the QUIC versions defined in RFC 9000 and
+// RFC 9369.
+const (
+	Version1 = 0x00000001
+	Version2 = 0x6b3343cf
+)
+
+const (
+	// maxReservedTransportParams is the maximum number of reserved
+	// transport parameters.
+	maxReservedTransportParams = 4
+	// maxReservedTransportParamLen is the maximum length of reserved
+	// transport parameter value.
+	maxReservedTransportParamLen = 16
+	// maxTransportParamN is the maximum N of reserved transport
+	// parameter ID of the form 31 * N + 27.
+	maxTransportParamN = (MaxVarint - 27) / 31
+	// maxAckDelayExponent is the maximum value of ack_delay_exponent.
+	maxAckDelayExponent = 20
+	// maxMaxAckDelay is the maximum value of max_ack_delay.
+	maxMaxAckDelay = 1<<14 - 1
+	// minMaxUDPPayloadSize is the minimum value of
+	// max_udp_payload_size.
+	minMaxUDPPayloadSize = 1200
+	// minActiveConnectionIDLimit is the minimum value of
+	// active_connection_id_limit.
+	minActiveConnectionIDLimit = 2
+)
+
+// clientTransportParams are the transport parameters both endpoints
+// may send.
+var clientTransportParams = []uint64{
+	TransportParamMaxIdleTimeout,
+	TransportParamMaxUDPPayloadSize,
+	TransportParamInitialMaxData,
+	TransportParamInitialMaxStreamDataBidiLocal,
+	TransportParamInitialMaxStreamDataBidiRemote,
+	TransportParamInitialMaxStreamDataUni,
+	TransportParamInitialMaxStreamsBidi,
+	TransportParamInitialMaxStreamsUni,
+	TransportParamAckDelayExponent,
+	TransportParamMaxAckDelay,
+	TransportParamDisableActiveMigration,
+	TransportParamActiveConnectionIDLimit,
+	TransportParamInitialSourceConnectionID,
+	TransportParamVersionInformation,
+	TransportParamMaxDatagramFrameSize,
+}
+
+// serverTransportParams are the transport parameters only server may
+// send.
+var serverTransportParams = []uint64{
+	TransportParamOriginalDestinationConnectionID,
+	TransportParamStatelessResetToken,
+	TransportParamPreferredAddress,
+	TransportParamRetrySourceConnectionID,
+}
+
+// TransportParameterOptions controls the transport parameters
+// generated by ConsumeTransportParameters.  The zero value generates
+// client transport parameters.
+type TransportParameterOptions struct {
+	// Server generates the transport parameters sent by server.
+	Server bool
+	// AllowIllegal allows transport parameters which violate RFC
+	// 9000, such as duplicate parameters, server-only parameters sent
+	// by client, out of range values and length fields which do not
+	// match value.
+	AllowIllegal bool
+}
+
+type transportParamGen struct {
+	fdp *fuzz.FuzzedDataProvider
+	// illegal is true if the transport parameter being generated may
+	// violate RFC 9000.
+	illegal bool
+}
+
+// ConsumeReservedTransportParam returns a reserved transport parameter
+// ID of the form 31 * N + 27 by consuming bytes from the input data.
+func ConsumeReservedTransportParam(fdp *fuzz.FuzzedDataProvider) uint64 {
+	return 31*fdp.ConsumeUint64InRange(0, maxTransportParamN) + 27
+}
+
+// ConsumeTransportParameters returns encoded QUIC transport parameters
+// which are carried in quic_transport_parameters TLS extension.  The
+// set of parameters, including reserved ones, and their order are
+// chosen by consuming bytes from the input data.  opts may be nil.
+func ConsumeTransportParameters(
+	fdp *fuzz.FuzzedDataProvider, opts *TransportParameterOptions,
+) []byte {
+	opts = gen.OrZero(opts)
+	g := &transportParamGen{fdp: fdp}
+
+	ids := clientTransportParams
+	if opts.Server || gen.ConsumeIllegal(fdp, opts.AllowIllegal) {
+		ids = append(ids[:len(ids):len(ids)], serverTransportParams...)
+	}
+
+	ids = fuzz.ConsumeSubset(fdp, ids)
+
+	reserved := fuzz.ConsumeSlice(fdp, maxReservedTransportParams,
+		ConsumeReservedTransportParam)
+	if !gen.ConsumeIllegal(fdp, opts.AllowIllegal) {
+		slices.Sort(reserved)
+		reserved = slices.Compact(reserved)
+	}
+
+	ids = append(ids, reserved...)
+
+	if gen.ConsumeIllegal(fdp, opts.AllowIllegal) {
+		ids = append(ids, fuzz.ConsumeSubset(fdp, ids)...)
+	}
+
+	fuzz.Shuffle(fdp, ids)
+
+	var b []byte
+
+	for _, id := range ids {
+		g.illegal = gen.ConsumeIllegal(fdp, opts.AllowIllegal)
+		b = AppendConsumedVarint(b, fdp, id)
+		b = appendLengthPrefixed(fdp, g.illegal, b, g.consumeValue(id))
+	}
+
+	return b
+}
+
+// consumeValue returns the value of transport parameter id.
+func (g *transportParamGen) consumeValue(id uint64) []byte {
+	fdp := g.fdp
+
+	switch id {
+	case TransportParamOriginalDestinationConnectionID,
+		TransportParamInitialSourceConnectionID,
+		TransportParamRetrySourceConnectionID:
+		return g.consumeConnectionID(0)
+	case TransportParamStatelessResetToken:
+		return gen.AppendZeroPadded(nil, fdp, statelessResetTokenLen)
+	case TransportParamMaxUDPPayloadSize:
+		return g.consumeVarintInRange(minMaxUDPPayloadSize, MaxVarint)
+	case TransportParamInitialMaxStreamsBidi,
+		TransportParamInitialMaxStreamsUni:
+		return g.consumeVarintInRange(0, maxStreams)
+	case TransportParamAckDelayExponent:
+		return g.consumeVarintInRange(0, maxAckDelayExponent)
+	case TransportParamMaxAckDelay:
+		return g.consumeVarintInRange(0, maxMaxAckDelay)
+	case TransportParamActiveConnectionIDLimit:
+		return g.consumeVarintInRange(minActiveConnectionIDLimit, MaxVarint)
+	case TransportParamDisableActiveMigration:
+		return nil
+	case TransportParamPreferredAddress:
+		// IPv4 address and port, and IPv6 address and port.
+		b := gen.AppendZeroPadded(nil, fdp, 4+2+16+2)
+		cid := g.consumeConnectionID(1)
+		b = append(b, byte(len(cid)))
+		b = append(b, cid...)
+
+		return gen.AppendZeroPadded(b, fdp, statelessResetTokenLen)
+	case TransportParamVersionInformation:
+		return g.consumeVersionInformation()
+	case TransportParamMaxIdleTimeout,
+		TransportParamInitialMaxData,
+		TransportParamInitialMaxStreamDataBidiLocal,
+		TransportParamInitialMaxStreamDataBidiRemote,
+		TransportParamInitialMaxStreamDataUni,
+		TransportParamMaxDatagramFrameSize:
+		return ConsumeVarintBytes(fdp)
+	default:
+		// Reserved transport parameter.
+		return fdp.ConsumeBytes(fdp.ConsumeIntInRange(0,
+			maxReservedTransportParamLen))
+	}
+}
+
+// consumeVarintInRange returns the encoding of a variable-length
+// integer in the range [minVal, maxVal].  If g.illegal is true, the
+// value might be out of range.
+func (g *transportParamGen) consumeVarintInRange(minVal, maxVal uint64) []byte {
+	v := ConsumeVarint(g.fdp)
+	if (v < minVal || v > maxVal) && !g.illegal {
+		v = minVal + v%(maxVal-minVal+1)
+	}
+
+	return ConsumeVarintEncoding(g.fdp, v)
+}
+
+// consumeConnectionID returns a connection ID whose length is at least
+// minLen.  If g.illegal is true, the length might exceed
+// MaxConnectionIDLen.
+func (g *transportParamGen) consumeConnectionID(minLen int) []byte {
+	fdp := g.fdp
+
+	if g.illegal {
+		return gen.AppendZeroPadded(nil, fdp, int(fdp.ConsumeUint8()))
+	}
+
+	cid := ConsumeConnectionID(fdp)
+	if len(cid) < minLen {
+		cid = append(cid, make([]byte, minLen-len(cid))...)
+	}
+
+	return cid
+}
+
+// consumeVersionInformation returns version_information transport
+// parameter value, which is Chosen Version followed by Available
+// Versions.
+func (g *transportParamGen) consumeVersionInformation() []byte {
+	fdp := g.fdp
+	versions := []uint32{Version1, Version2}
+
+	chosen := versions[fdp.ConsumeIntInRange(0, len(versions)-1)]
+	if g.illegal && fdp.ConsumeBool() {
+		chosen = fdp.ConsumeUint32()
+	}
+
+	available := append([]uint32{chosen}, fuzz.ConsumeSubset(fdp, versions)...)
+	fuzz.Shuffle(fdp, available)
+
+	b := appendUint32(nil, chosen)
+
+	for _, v := range available {
+		b = appendUint32(b, v)
+	}
+
+	return b
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
@@ -0,0 +1,115 @@
+package quic
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	fuzz "github.com/ngtcp2/fuzzeddataprovider-go"
+	"github.com/ngtcp2/fuzzeddataprovider-go/internal/gentest"
+)
+
+// parseTransportParameters validates the transport parameters in b
+// according to RFC 9000.
+func parseTransportParameters(b []byte, server bool) error {
+	return gentest.Parse(func() {
+		newFrameReader(b).transportParameters(server)
+	})
+}
+
+func (r *frameReader) transportParameters(server bool) {
+	seen := make(map[uint64]bool)
+
+	for len(r.B) != 0 {
+		id := r.varint()
+		v := newFrameReader(r.Bytes(r.varint()))
+
+		r.Check(!seen[id])
+		r.Check(server || !slices.Contains(serverTransportParams, id))
+
+		seen[id] = true
+
+		switch id {
+		case TransportParamOriginalDestinationConnectionID,
+			TransportParamInitialSourceConnectionID,
+			TransportParamRetrySourceConnectionID:
+			r.Check(len(v.B) <= MaxConnectionIDLen)
+
+			v.B = nil
+		case TransportParamStatelessResetToken:
+			v.Bytes(statelessResetTokenLen)
+		case TransportParamMaxUDPPayloadSize:
+			r.Check(v.varint() >= minMaxUDPPayloadSize)
+		case TransportParamInitialMaxStreamsBidi,
+			TransportParamInitialMaxStreamsUni:
+			r.Check(v.varint() <= maxStreams)
+		case TransportParamAckDelayExponent:
+			r.Check(v.varint() <= maxAckDelayExponent)
+		case TransportParamMaxAckDelay:
+			r.Check(v.varint() <= maxMaxAckDelay)
+		case TransportParamActiveConnectionIDLimit:
+			r.Check(v.varint() >= minActiveConnectionIDLimit)
+		case TransportParamDisableActiveMigration:
+		case TransportParamPreferredAddress:
+			v.Bytes(4 + 2 + 16 + 2)
+			cidlen := v.Bytes(1)[0]
+			r.Check(cidlen >= 1 && cidlen <= MaxConnectionIDLen)
+			v.Bytes(uint64(cidlen))
+			v.Bytes(statelessResetTokenLen)
+		case TransportParamVersionInformation:
+			r.Check(len(v.B) >= 8 && len(v.B)%4 == 0)
+
+			chosen := v.Bytes(4)
+			r.Check(slices.Equal(chosen, []byte{0, 0, 0, 1}) ||
+				slices.Equal(chosen, []byte{0x6b, 0x33, 0x43, 0xcf}))
+
+			v.B = nil
+		case TransportParamMaxIdleTimeout,
+			TransportParamInitialMaxData,
+			TransportParamInitialMaxStreamDataBidiLocal,
+			TransportParamInitialMaxStreamDataBidiRemote,
+			TransportParamInitialMaxStreamDataUni,
+			TransportParamMaxDatagramFrameSize:
+			v.varint()
+		default:
+			r.Check(id%31 == 27)
+
+			v.B = nil
+		}
+
+		r.Check(len(v.B) == 0)
+	}
+}
+
+func TestConsumeReservedTransportParam(t *testing.T) {
+	fdp := fuzz.NewFuzzedDataProvider([]byte{0x01})
+
+	assert.Equal(t, uint64(58), ConsumeReservedTransportParam(fdp))
+	assert.Equal(t, uint64(TransportParamReserved),
+		ConsumeReservedTransportParam(fdp))
+}
+
+func TestConsumeTransportParameters(t *testing.T) {
+	fdp := fuzz.NewFuzzedDataProvider(nil)
+
+	assert.Empty(t, ConsumeTransportParameters(fdp, nil))
+
+	// Only disable_active_migration is chosen.
+	fdp = fuzz.NewFuzzedDataProvider([]byte{0x00, 0x04})
+
+	assert.Equal(t, []byte{TransportParamDisableActiveMigration, 0x00},
+		ConsumeTransportParameters(fdp, nil))
+}
+
+func TestConsumeTransportParametersProperty(t *testing.T) {
+	for _, server := range []bool{false, true} {
+		gentest.Check(t, func(data []byte, illegal bool) []byte {
+			return ConsumeTransportParameters(fuzz.NewFuzzedDataProvider(data),
+				&TransportParameterOptions{
+					Server:       server,
+					AllowIllegal: illegal,
+				})
+		}, func(b []byte) error { return parseTransportParameters(b, server) })
+	}
+}
@@ -0,0 +1,118 @@
+// Package quic provides helpers which generate QUIC wire format fields
+// from FuzzedDataProvider.
+package quic
+
+import fuzz "github.com/ngtcp2/fuzzeddataprovider-go"
+
+// MaxVarint is the maximum value of QUIC variable-length integer.
+const MaxVarint = 1<<62 - 1
+
+// varintClasses are the ranges of values which are encoded in 1, 2, 4
+// and 8 bytes respectively.
+var varintClasses = [][2]uint64{
+	{0, 1<<6 - 1},
+	{1 << 6, 1<<14 - 1},
+	{1 << 14, 1<<30 - 1},
+	{1 << 30, MaxVarint},
+}
+
+// VarintLen returns the minimum number of bytes required to encode v.
+// v must be less than or equal to MaxVarint.
+func VarintLen(v uint64) int {
+	switch {
+	case v < 1<<6:
+		return 1
+	case v < 1<<14:
+		return 2
+	case v < 1<<30:
+		return 4
+	default:
+		return 8
+	}
+}
+
+// AppendVarint appends the minimal encoding of v to b.  v must be less
+// than or equal to MaxVarint.
+func AppendVarint(b []byte, v uint64) []byte {
+	return AppendVarintN(b, v, VarintLen(v))
+}
+
+// AppendVarintN appends v encoded in n bytes to b.  n must be 1, 2, 4
+// or 8, and must be greater than or equal to VarintLen(v).  If n is
+// greater than VarintLen(v), the encoding is not minimal, which is
+// permitted by RFC 9000 except for frame types.
+func AppendVarintN(b []byte, v uint64, n int) []byte {
+	if n < VarintLen(v) {
+		panic("n < VarintLen(v)")
+	}
+
+	var prefix byte
+
+	switch n {
+	case 1:
+	case 2:
+		prefix = 0x40
+	case 4:
+		prefix = 0x80
+	case 8:
+		prefix = 0xc0
+	default:
+		panic("n must be 1, 2, 4 or 8")
+	}
+
+	for i := n - 1; i >= 0; i-- {
+		c := byte(v >> (i * 8))
+		if i == n-1 {
+			c |= prefix
+		}
+
+		b = append(b, c)
+	}
+
+	return b
+}
+
+// ConsumeVarint returns a value in the range [0, MaxVarint] by
+// consuming bytes from the input data.  It first chooses the length of
+// the minimal encoding, and then the value in that length, so that
+// short and long encodings are equally likely.  If there is no input
+// data left, it always returns 0.
+func ConsumeVarint(fdp *fuzz.FuzzedDataProvider) uint64 {
+	c := varintClasses[fdp.ConsumeIntInRange(0, len(varintClasses)-1)]
+
+	return fdp.ConsumeUint64InRange(c[0], c[1])
+}
+
+// ConsumeVarintEncoding returns v encoded in the length chosen by
+// consuming bytes from the input data.  The length is at least
+// VarintLen(v), and might be longer than necessary.  If there is no
+// input data left, it returns the minimal encoding.  v must be less
+// than or equal to MaxVarint.
+func ConsumeVarintEncoding(fdp *fuzz.FuzzedDataProvider, v uint64) []byte {
+	return AppendConsumedVarint(nil, fdp, v)
+}
+
+// AppendConsumedVarint appends the encoding returned by
+// ConsumeVarintEncoding to b.
+func AppendConsumedVarint(
+	b []byte, fdp *fuzz.FuzzedDataProvider, v uint64,
+) []byte {
+	var minExp int
+
+	switch VarintLen(v) {
+	case 2:
+		minExp = 1
+	case 4:
+		minExp = 2
+	case 8:
+		minExp = 3
+	}
+
+	return AppendVarintN(b, v, 1<<fdp.ConsumeIntInRange(minExp, 3))
+}
+
+// ConsumeVarintBytes returns a value returned by ConsumeVarint encoded
+// by ConsumeVarintEncoding.
+func ConsumeVarintBytes(fdp *fuzz.FuzzedDataProvider) []byte {
+	return ConsumeVarintEncoding(fdp, ConsumeVarint(fdp))
+}
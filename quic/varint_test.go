@@ -0,0 +1,66 @@
+package quic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	fuzz "github.com/ngtcp2/fuzzeddataprovider-go"
+)
+
+func TestVarintLen(t *testing.T) {
+	assert.Equal(t, 1, VarintLen(0))
+	assert.Equal(t, 1, VarintLen(63))
+	assert.Equal(t, 2, VarintLen(64))
+	assert.Equal(t, 2, VarintLen(16383))
+	assert.Equal(t, 4, VarintLen(16384))
+	assert.Equal(t, 4, VarintLen(1<<30-1))
+	assert.Equal(t, 8, VarintLen(1<<30))
+	assert.Equal(t, 8, VarintLen(MaxVarint))
+}
+
+func TestAppendVarint(t *testing.T) {
+	// Examples from RFC 9000 Appendix A.1.
+	assert.Equal(t,
+		[]byte{0xc2, 0x19, 0x7c, 0x5e, 0xff, 0x14, 0xe8, 0x8c},
+		AppendVarint(nil, 151288809941952652))
+	assert.Equal(t, []byte{0x9d, 0x7f, 0x3e, 0x7d},
+		AppendVarint(nil, 494878333))
+	assert.Equal(t, []byte{0x7b, 0xbd}, AppendVarint(nil, 15293))
+	assert.Equal(t, []byte{0x25}, AppendVarint(nil, 37))
+	assert.Equal(t, []byte{0x40, 0x25}, AppendVarintN(nil, 37, 2))
+	assert.Equal(t, []byte{0xc0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x25},
+		AppendVarintN(nil, 37, 8))
+
+	assert.Panics(t, func() { AppendVarintN(nil, 64, 1) })
+	assert.Panics(t, func() { AppendVarintN(nil, 37, 3) })
+}
+
+func TestConsumeVarint(t *testing.T) {
+	fdp := fuzz.NewFuzzedDataProvider(
+		[]byte{0x01, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x01, 0x25, 0x00})
+
+	assert.Equal(t, uint64(37), ConsumeVarint(fdp))
+	assert.Equal(t, uint64(64), ConsumeVarint(fdp))
+	assert.Equal(t, uint64(1<<14+1), ConsumeVarint(fdp))
+	assert.Equal(t, uint64(0), ConsumeVarint(fdp))
+}
+
+func TestConsumeVarintEncoding(t *testing.T) {
+	fdp := fuzz.NewFuzzedDataProvider([]byte{0x00, 0x03, 0x02})
+
+	assert.Equal(t, []byte{0x80, 0x00, 0x00, 0x25},
+		ConsumeVarintEncoding(fdp, 37))
+	assert.Equal(t, []byte{0xc0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x40, 0x00},
+		ConsumeVarintEncoding(fdp, 16384))
+	assert.Equal(t, []byte{0x80, 0x00, 0x40, 0x00},
+		ConsumeVarintEncoding(fdp, 16384))
+	assert.Equal(t, []byte{0x25}, ConsumeVarintEncoding(fdp, 37))
+}
+
+func TestConsumeVarintBytes(t *testing.T) {
+	fdp := fuzz.NewFuzzedDataProvider([]byte{0x00, 0x25, 0x00})
+
+	assert.Equal(t, []byte{0x25}, ConsumeVarintBytes(fdp))
+	assert.Equal(t, []byte{0x00}, ConsumeVarintBytes(fdp))
+}
@@ -0,0 +1,36 @@
+package fuzz
+
+import "math/rand/v2"
+
+// Source is a math/rand/v2 Source which produces values by consuming
+// bytes from the input data, so that randomness inside the code under
+// test is controlled by the fuzzer.
+type Source struct {
+	fdp      *FuzzedDataProvider
+	fallback rand.Source
+}
+
+// NewSource returns new Source.  fallback is used once the input data
+// is exhausted.  If fallback is nil, a PCG seeded with 0 is used.  Note
+// that a fallback which always returns the same value makes some
+// methods of rand.Rand, such as IntN, loop forever.
+func (fdp *FuzzedDataProvider) NewSource(fallback rand.Source) *Source {
+	if fallback == nil {
+		fallback = rand.NewPCG(0, 0)
+	}
+
+	return &Source{
+		fdp:      fdp,
+		fallback: fallback,
+	}
+}
+
+// Uint64 returns the value returned by ConsumeUint64, or the value
+// returned by the fallback source if there is no input data left.
+func (s *Source) Uint64() uint64 {
+	if s.fdp.RemainingBytes() == 0 {
+		return s.fallback.Uint64()
+	}
+
+	return s.fdp.ConsumeUint64()
+}
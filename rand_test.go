@@ -0,0 +1,26 @@
+package fuzz
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSource(t *testing.T) {
+	fdp := NewFuzzedDataProvider(
+		[]byte{0xba, 0xad, 0xf0, 0x0d, 0xde, 0xad, 0xbe, 0xef, 0x01})
+	src := fdp.NewSource(nil)
+
+	assert.Equal(t, uint64(0x01efbeadde0df0ad), src.Uint64())
+	assert.Equal(t, uint64(0xba), src.Uint64())
+	assert.Equal(t, rand.NewPCG(0, 0).Uint64(), src.Uint64())
+
+	fdp = NewFuzzedDataProvider([]byte{0x02})
+	r := rand.New(fdp.NewSource(rand.NewPCG(1, 2)))
+	want := rand.New(rand.NewPCG(1, 2))
+
+	assert.Equal(t, uint64(0x02), r.Uint64())
+	assert.Equal(t, want.Uint64(), r.Uint64())
+	assert.Equal(t, want.IntN(3), r.IntN(3))
+}
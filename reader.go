@@ -0,0 +1,87 @@
+package fuzz
+
+import "io"
+
+const (
+	readData = iota
+	readNothing
+	readError
+)
+
+// readWeights are the weights of readData, readNothing and readError.
+var readWeights = []uint{14, 1, 1}
+
+// Reader is an io.Reader which returns a payload in chunks whose sizes
+// are chosen by consuming bytes from the input data.  It also returns
+// zero-length reads and injected errors at the points chosen in the
+// same way.
+type Reader struct {
+	fdp  *FuzzedDataProvider
+	data []byte
+	errs []error
+	err  error
+}
+
+// NewReader returns new Reader which returns payload.  errs are the
+// errors which Reader may inject in the middle of payload.  If errs is
+// empty, io.ErrUnexpectedEOF is injected instead.  Reader does not
+// copy payload.
+func (fdp *FuzzedDataProvider) NewReader(
+	payload []byte, errs ...error,
+) *Reader {
+	return &Reader{
+		fdp:  fdp,
+		data: payload,
+		errs: errs,
+	}
+}
+
+// Read implements io.Reader.  Once Read returns an error, it keeps
+// returning the same error.  When the payload is fully read, it
+// returns io.EOF either along with the last chunk or on the next call,
+// which is chosen by consuming bytes from the input data.  If there is
+// no input data left, it returns 1 byte per call.
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if len(r.data) == 0 {
+		r.err = io.EOF
+
+		return 0, r.err
+	}
+
+	m := min(len(p), len(r.data))
+
+	switch r.fdp.ConsumeWeightedIndex(readWeights) {
+	case readNothing:
+		return 0, nil
+	case readError:
+		if len(r.errs) == 0 {
+			r.err = io.ErrUnexpectedEOF
+		} else {
+			r.err = r.errs[r.fdp.ConsumeIntInRange(0, len(r.errs)-1)]
+		}
+
+		n := copy(p, r.data[:r.fdp.ConsumeIntInRange(0, m)])
+		r.data = r.data[n:]
+
+		return n, r.err
+	}
+
+	n := copy(p, r.data[:r.fdp.ConsumeIntInRange(1, m)])
+	r.data = r.data[n:]
+
+	if len(r.data) == 0 && r.fdp.ConsumeBool() {
+		r.err = io.EOF
+
+		return n, r.err
+	}
+
+	return n, nil
+}
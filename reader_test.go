@@ -0,0 +1,91 @@
+package fuzz
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReader(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{0x01, 0x00, 0x0e, 0x01, 0x00})
+	r := fdp.NewReader([]byte("foo bar"))
+
+	buf := make([]byte, 4)
+
+	n, err := r.Read(buf)
+
+	require.NoError(t, err)
+	assert.Equal(t, "fo", string(buf[:n]))
+
+	n, err = r.Read(buf)
+
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	n, err = r.Read(buf)
+
+	require.NoError(t, err)
+	assert.Equal(t, "o ", string(buf[:n]))
+
+	n, err = r.Read(buf[:0])
+
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	b, err := io.ReadAll(r)
+
+	require.NoError(t, err)
+	assert.Equal(t, "bar", string(b))
+
+	n, err = r.Read(buf)
+
+	require.ErrorIs(t, err, io.EOF)
+	assert.Zero(t, n)
+}
+
+func TestReaderEOF(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{0x01, 0x02, 0x00})
+	r := fdp.NewReader([]byte("foo"))
+
+	buf := make([]byte, 4)
+
+	n, err := r.Read(buf)
+
+	require.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, "foo", string(buf[:n]))
+
+	n, err = r.Read(buf)
+
+	require.ErrorIs(t, err, io.EOF)
+	assert.Zero(t, n)
+}
+
+func TestReaderError(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{0x01, 0x0f})
+	r := fdp.NewReader([]byte("foo"))
+
+	buf := make([]byte, 4)
+
+	n, err := r.Read(buf)
+
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Equal(t, "f", string(buf[:n]))
+
+	n, err = r.Read(buf)
+
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Zero(t, n)
+
+	errReset := errors.New("connection reset")
+
+	fdp = NewFuzzedDataProvider([]byte{0x00, 0x01, 0x0f})
+	r = fdp.NewReader([]byte("foo"), io.ErrNoProgress, errReset)
+
+	n, err = r.Read(buf)
+
+	require.ErrorIs(t, err, errReset)
+	assert.Zero(t, n)
+}
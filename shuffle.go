@@ -0,0 +1,47 @@
+package fuzz
+
+import "math"
+
+// ConsumePermutation returns a permutation of the integers [0, n)
+// produced by Fisher-Yates shuffle.  If there is no input data left,
+// it returns the integers in the order 1, 2, ..., n-1, 0.
+func (fdp *FuzzedDataProvider) ConsumePermutation(n int) []int {
+	res := make([]int, n)
+	for i := range res {
+		res[i] = i
+	}
+
+	Shuffle(fdp, res)
+
+	return res
+}
+
+// Shuffle shuffles s in place using Fisher-Yates shuffle.  The swap
+// at each step is chosen by consuming bytes from the input data.
+func Shuffle[T any](fdp *FuzzedDataProvider, s []T) {
+	for i := len(s) - 1; i > 0; i-- {
+		j := fdp.ConsumeIntInRange(0, i)
+		s[i], s[j] = s[j], s[i]
+	}
+}
+
+// ConsumeSubset returns a new slice containing a subset of s in the
+// original order.  Each element is included if the corresponding bit
+// of a bitmask consumed from the input data is set.  If there is no
+// input data left, it returns an empty slice.
+func ConsumeSubset[T any](fdp *FuzzedDataProvider, s []T) []T {
+	var res []T
+
+	for start := 0; start < len(s); start += 64 {
+		k := min(64, len(s)-start)
+		mask := fdp.ConsumeUint64InRange(0, math.MaxUint64>>(64-k))
+
+		for i := range k {
+			if mask&(1<<i) != 0 {
+				res = append(res, s[start+i])
+			}
+		}
+	}
+
+	return res
+}
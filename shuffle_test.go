@@ -0,0 +1,37 @@
+package fuzz
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsumePermutation(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{0x01, 0x00, 0x02, 0x03})
+
+	assert.Equal(t, []int{4, 1, 0, 2, 3}, fdp.ConsumePermutation(5))
+	assert.Equal(t, []int{1, 2, 3, 0}, fdp.ConsumePermutation(4))
+	assert.Empty(t, fdp.ConsumePermutation(0))
+}
+
+func TestShuffle(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{0x01, 0x01})
+
+	s := []string{"a", "b", "c"}
+	Shuffle(fdp, s)
+
+	assert.Equal(t, []string{"a", "c", "b"}, s)
+}
+
+func TestConsumeSubset(t *testing.T) {
+	s := make([]int, 70)
+	for i := range s {
+		s[i] = i
+	}
+
+	fdp := NewFuzzedDataProvider(
+		[]byte{0x21, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x80, 0x05})
+
+	assert.Equal(t, []int{55, 56, 58, 64, 69}, ConsumeSubset(fdp, s))
+	assert.Empty(t, ConsumeSubset(fdp, s))
+}
@@ -0,0 +1,73 @@
+package fuzz
+
+import (
+	"bytes"
+	"math"
+)
+
+// SplitSeparator is the magic string which delimits the regions taken
+// by SubProvider and Split, in the same way as libFuzzer users split
+// the input with a separator.  Adding it to the fuzzer's dictionary
+// helps the fuzzer to build inputs with several regions.
+const SplitSeparator = "FUZZ_SPLIT"
+
+// SubProvider returns new FuzzedDataProvider over a region taken from
+// the front of the input data.  The region extends to the next
+// SplitSeparator, or to the end of the data if there is none, and
+// SubProvider consumes the region and the separator.  The returned
+// provider gets at most maxLen bytes of the region, and the rest is
+// skipped.  Because the region is delimited by the separator, inserting
+// or removing bytes inside the region does not change the data which
+// follow it, unless it makes or breaks a separator.  The returned
+// provider shares the dictionary of fdp, and consuming from it does not
+// affect fdp.  If maxLen is not positive, it returns an empty provider
+// without consuming any data.
+func (fdp *FuzzedDataProvider) SubProvider(maxLen int) *FuzzedDataProvider {
+	sub := &FuzzedDataProvider{
+		dict: fdp.dict,
+	}
+
+	if maxLen <= 0 {
+		return sub
+	}
+
+	n := bytes.Index(fdp.data, []byte(SplitSeparator))
+	next := n + len(SplitSeparator)
+
+	if n < 0 {
+		n = len(fdp.data)
+		next = n
+	}
+
+	n = min(n, maxLen)
+
+	sub.data = fdp.data[:n:n]
+	fdp.advance(next)
+
+	return sub
+}
+
+// Split returns n FuzzedDataProviders over disjoint regions of the
+// input data.  The first n-1 providers are made by SubProvider without
+// limiting their length, and the last one takes all of the data that
+// are left.  It consumes all remaining input data.  If n is not
+// positive, it returns nil without consuming any data.
+func (fdp *FuzzedDataProvider) Split(n int) []*FuzzedDataProvider {
+	if n <= 0 {
+		return nil
+	}
+
+	subs := make([]*FuzzedDataProvider, 0, n)
+
+	for range n - 1 {
+		subs = append(subs, fdp.SubProvider(math.MaxInt))
+	}
+
+	last := &FuzzedDataProvider{
+		data: fdp.data,
+		dict: fdp.dict,
+	}
+	fdp.advance(len(fdp.data))
+
+	return append(subs, last)
+}
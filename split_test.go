@@ -0,0 +1,124 @@
+package fuzz
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// join joins regions with SplitSeparator.
+func join(regions ...string) []byte {
+	var b []byte
+
+	for i, r := range regions {
+		if i != 0 {
+			b = append(b, SplitSeparator...)
+		}
+
+		b = append(b, r...)
+	}
+
+	return b
+}
+
+func TestSubProvider(t *testing.T) {
+	fdp := NewFuzzedDataProvider(join("\xba\xad", "", "\xf0\x0d\xca", "\xfe"))
+	fdp.SetDictionary([]string{"foo"})
+
+	sub := fdp.SubProvider(255)
+
+	assert.Equal(t, []byte{0xba, 0xad}, sub.ConsumeRemainingBytes())
+	assert.Equal(t, []string{"foo"}, sub.Dictionary())
+
+	// Empty region.
+	sub = fdp.SubProvider(255)
+
+	assert.Equal(t, 0, sub.RemainingBytes())
+
+	// The rest of the region beyond maxLen is skipped.
+	sub = fdp.SubProvider(2)
+
+	assert.Equal(t, []byte{0xf0, 0x0d}, sub.ConsumeRemainingBytes())
+	assert.Equal(t, 1, fdp.RemainingBytes())
+
+	// Without separator, the region extends to the end of the data.
+	sub = fdp.SubProvider(255)
+
+	assert.Equal(t, []byte{0xfe}, sub.ConsumeRemainingBytes())
+	assert.Equal(t, 0, fdp.RemainingBytes())
+
+	sub = fdp.SubProvider(255)
+
+	assert.Equal(t, 0, sub.RemainingBytes())
+}
+
+func TestSubProviderNonPositive(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{0x01, 0x02})
+
+	assert.Equal(t, 0, fdp.SubProvider(0).RemainingBytes())
+	assert.Equal(t, 2, fdp.RemainingBytes())
+}
+
+func TestSubProviderIndependent(t *testing.T) {
+	fdp := NewFuzzedDataProvider(join("\xba\xad", "\xf0\x0d"))
+	sub := fdp.SubProvider(255)
+
+	assert.Equal(t, uint8(0xad), sub.ConsumeUint8())
+	assert.Equal(t, uint8(0x0d), fdp.ConsumeUint8())
+	assert.Equal(t, []byte{0xba}, sub.ConsumeRemainingBytes())
+	assert.Equal(t, []byte{0xf0}, fdp.ConsumeRemainingBytes())
+}
+
+func TestSplit(t *testing.T) {
+	fdp := NewFuzzedDataProvider(join("\xba", "\xad\xf0", "\x0d", "\xfe"))
+	fdp.SetDictionary([]string{"foo"})
+
+	subs := fdp.Split(3)
+
+	assert.Len(t, subs, 3)
+	assert.Equal(t, []byte{0xba}, subs[0].ConsumeRemainingBytes())
+	assert.Equal(t, []byte{0xad, 0xf0}, subs[1].ConsumeRemainingBytes())
+	// The last provider takes the rest, including separators.
+	assert.Equal(t, join("\x0d", "\xfe"), subs[2].ConsumeRemainingBytes())
+	assert.Equal(t, []string{"foo"}, subs[2].Dictionary())
+	assert.Equal(t, 0, fdp.RemainingBytes())
+
+	assert.Nil(t, fdp.Split(0))
+}
+
+func TestSplitStable(t *testing.T) {
+	regions := []string{"\xba\xad", "\xf0\x0d", "\xca\xfe"}
+
+	var want [][]byte
+
+	for _, sub := range NewFuzzedDataProvider(join(regions...)).Split(3) {
+		want = append(want, sub.ConsumeRemainingBytes())
+	}
+
+	for i := range regions {
+		for _, mutate := range []func(string) string{
+			// Overwriting.
+			func(s string) string { return "\xff" + s[1:] },
+			// Inserting.
+			func(s string) string { return s[:1] + "\xff\xff" + s[1:] },
+			// Removing.
+			func(s string) string { return s[1:] },
+		} {
+			mutated := slices.Clone(regions)
+			mutated[i] = mutate(mutated[i])
+			subs := NewFuzzedDataProvider(join(mutated...)).Split(3)
+
+			// Mutating region i does not affect the other regions.
+			for j, sub := range subs {
+				got := sub.ConsumeRemainingBytes()
+
+				if j == i {
+					assert.Equal(t, []byte(mutated[i]), got)
+				} else {
+					assert.Equal(t, want[j], got)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package fuzz
+
+import (
+	"math"
+	"time"
+)
+
+// ConsumeDuration returns a duration in the range [minVal, maxVal] by
+// consuming bytes from the input data.  The value might not be
+// uniformly distributed in the given range.  If there is no input data
+// left, it always returns minVal.  minVal must be less than or equal to
+// maxVal.
+func (fdp *FuzzedDataProvider) ConsumeDuration(
+	minVal, maxVal time.Duration,
+) time.Duration {
+	return consumeIntegralInRange(fdp, minVal, maxVal)
+}
+
+// ConsumeTime returns a time in the range [minVal, maxVal] by
+// consuming bytes from the input data.  The returned time is in UTC
+// and has no monotonic clock reading.  The value might not be
+// uniformly distributed in the given range.  If there is no input data
+// left, it always returns minVal.  minVal must not be after maxVal.
+func (fdp *FuzzedDataProvider) ConsumeTime(minVal, maxVal time.Time) time.Time {
+	if minVal.After(maxVal) {
+		panic("minVal > maxVal")
+	}
+
+	minSec, maxSec := minVal.Unix(), maxVal.Unix()
+	sec := fdp.ConsumeInt64InRange(minSec, maxSec)
+
+	minNsec, maxNsec := 0, int(time.Second-1)
+
+	if sec == minSec {
+		minNsec = minVal.Nanosecond()
+	}
+
+	if sec == maxSec {
+		maxNsec = maxVal.Nanosecond()
+	}
+
+	nsec := fdp.ConsumeIntInRange(minNsec, maxNsec)
+
+	return time.Unix(sec, int64(nsec)).UTC()
+}
+
+// boundaryTimes are the times which often reveal bugs in date
+// handling code.
+var boundaryTimes = []time.Time{
+	// The zero time.
+	{},
+	// Unix epoch and the nanosecond before it.
+	time.Unix(0, 0).UTC(),
+	time.Unix(-1, int64(time.Second-1)).UTC(),
+	// The range of Unix time in int64 nanoseconds.
+	time.Unix(0, math.MinInt64).UTC(),
+	time.Unix(0, math.MaxInt64).UTC(),
+	// The end of Unix time in int32 seconds.
+	time.Unix(math.MaxInt32, 0).UTC(),
+	// The last nanoseconds before and the first ones after leap
+	// seconds.  time.Time cannot represent 23:59:60.
+	time.Date(1972, 6, 30, 23, 59, 59, int(time.Second-1), time.UTC),
+	time.Date(1972, 7, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(2016, 12, 31, 23, 59, 59, int(time.Second-1), time.UTC),
+	time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
+	// Leap day.
+	time.Date(2000, 2, 29, 0, 0, 0, 0, time.UTC),
+	// The last time with 4-digit year, and year 10000.
+	time.Date(9999, 12, 31, 23, 59, 59, int(time.Second-1), time.UTC),
+	time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC),
+}
+
+// ConsumeTimeBiased returns a time in the range [minVal, maxVal] like
+// ConsumeTime, but about half of the time it returns minVal, maxVal or
+// one of the boundary times in the range, such as the zero time, Unix
+// epoch, year 10000 and the instants around leap seconds.  If there is
+// no input data left, it always returns minVal.  minVal must not be
+// after maxVal.
+func (fdp *FuzzedDataProvider) ConsumeTimeBiased(
+	minVal, maxVal time.Time,
+) time.Time {
+	if minVal.After(maxVal) {
+		panic("minVal > maxVal")
+	}
+
+	if !fdp.ConsumeBool() {
+		return fdp.ConsumeTime(minVal, maxVal)
+	}
+
+	candidates := []time.Time{minVal.UTC(), maxVal.UTC()}
+
+	for _, t := range boundaryTimes {
+		if !t.Before(minVal) && !t.After(maxVal) {
+			candidates = append(candidates, t)
+		}
+	}
+
+	return candidates[fdp.ConsumeIntInRange(0, len(candidates)-1)]
+}
+
+// locations are the fixed time zones returned by ConsumeLocation.
+// They cover the extreme and unusual UTC offsets in use, and do not
+// depend on the time zone database of the system.
+var locations = []*time.Location{
+	time.UTC,
+	time.FixedZone("UTC-12", -12*60*60),
+	time.FixedZone("UTC-9:30", -(9*60+30)*60),
+	time.FixedZone("UTC-1", -1*60*60),
+	time.FixedZone("UTC+0:19:32", 19*60+32),
+	time.FixedZone("UTC+1", 1*60*60),
+	time.FixedZone("UTC+5:30", (5*60+30)*60),
+	time.FixedZone("UTC+5:45", (5*60+45)*60),
+	time.FixedZone("UTC+8:45", (8*60+45)*60),
+	time.FixedZone("UTC+12:45", (12*60+45)*60),
+	time.FixedZone("UTC+14", 14*60*60),
+}
+
+// ConsumeLocation returns one of the fixed time zones chosen by
+// consuming bytes from the input data.  If there is no input data
+// left, it always returns time.UTC.
+func (fdp *FuzzedDataProvider) ConsumeLocation() *time.Location {
+	return locations[fdp.ConsumeIntInRange(0, len(locations)-1)]
+}
@@ -0,0 +1,64 @@
+package fuzz
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsumeDuration(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{0x01, 0xf4})
+
+	assert.Equal(t, -500*time.Millisecond+0xf401,
+		fdp.ConsumeDuration(-500*time.Millisecond, time.Second))
+	assert.Equal(t, -500*time.Millisecond,
+		fdp.ConsumeDuration(-500*time.Millisecond, time.Second))
+}
+
+func TestConsumeTime(t *testing.T) {
+	minVal := time.Date(2026, 1, 1, 0, 0, 0, 500, time.UTC)
+	maxVal := time.Date(2026, 1, 1, 0, 0, 2, 100, time.UTC)
+
+	fdp := NewFuzzedDataProvider([]byte{0x00, 0x00, 0x00, 0x63, 0x02})
+
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 2, 99, time.UTC),
+		fdp.ConsumeTime(minVal, maxVal))
+	assert.Equal(t, minVal, fdp.ConsumeTime(minVal, maxVal))
+
+	local := time.Now()
+
+	assert.Equal(t, local.Round(0).UTC(), fdp.ConsumeTime(local, local))
+
+	assert.Panics(t, func() { fdp.ConsumeTime(maxVal, minVal) })
+}
+
+func TestConsumeTimeBiased(t *testing.T) {
+	minVal := time.Date(1999, 1, 1, 0, 0, 0, 0, time.UTC)
+	maxVal := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	fdp := NewFuzzedDataProvider([]byte{0x00, 0x01, 0x04, 0x01, 0x01, 0x01})
+
+	assert.Equal(t, maxVal, fdp.ConsumeTimeBiased(minVal, maxVal))
+	assert.Equal(t, time.Date(2000, 2, 29, 0, 0, 0, 0, time.UTC),
+		fdp.ConsumeTimeBiased(minVal, maxVal))
+	assert.Equal(t, minVal, fdp.ConsumeTimeBiased(minVal, maxVal))
+	assert.Equal(t, minVal, fdp.ConsumeTimeBiased(minVal, maxVal))
+
+	fdp = NewFuzzedDataProvider([]byte{0x00, 0x01})
+
+	assert.Equal(t, time.Time{},
+		fdp.ConsumeTimeBiased(time.Time{}, time.Unix(0, 0)))
+}
+
+func TestConsumeLocation(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{0x0a, 0x04})
+
+	ts := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, "1970-01-01T00:19:32+00:19",
+		time.Unix(0, 0).In(fdp.ConsumeLocation()).Format(time.RFC3339))
+	assert.Equal(t, "2026-01-01T14:00:00+14:00",
+		ts.In(fdp.ConsumeLocation()).Format(time.RFC3339))
+	assert.Equal(t, time.UTC, fdp.ConsumeLocation())
+}
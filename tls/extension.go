@@ -0,0 +1,361 @@
+package tls
+
+import (
+	"encoding/binary"
+	"slices"
+
+	fuzz "github.com/ngtcp2/fuzzeddataprovider-go"
+	"github.com/ngtcp2/fuzzeddataprovider-go/internal/gen"
+	"github.com/ngtcp2/fuzzeddataprovider-go/quic"
+)
+
+// Extension types defined in RFC 8446, RFC 7301 and RFC 9001.
+const (
+	ExtensionServerName              = 0
+	ExtensionSupportedGroups         = 10
+	ExtensionSignatureAlgorithms     = 13
+	ExtensionALPN                    = 16
+	ExtensionPreSharedKey            = 41
+	ExtensionEarlyData               = 42
+	ExtensionSupportedVersions       = 43
+	ExtensionPSKKeyExchangeModes     = 45
+	ExtensionKeyShare                = 51
+	ExtensionQUICTransportParameters = 57
+	// ExtensionGREASE is the smallest GREASE extension type defined in
+	// RFC 8701.  In extension lists, it stands for all GREASE
+	// extension types.
+	ExtensionGREASE = 0x0a0a
+)
+
+// Named groups defined in RFC 8446 and draft-ietf-tls-ecdhe-mlkem.
+const (
+	GroupSecp256r1      = 0x0017
+	GroupSecp384r1      = 0x0018
+	GroupSecp521r1      = 0x0019
+	GroupX25519         = 0x001d
+	GroupX448           = 0x001e
+	GroupFFDHE2048      = 0x0100
+	GroupX25519MLKEM768 = 0x11ec
+)
+
+const (
+	// maxProtocolLen is the maximum length of ALPN protocol name.
+	maxProtocolLen = 255
+	// maxEarlyDataSizeQUIC is max_early_data_size in QUIC.
+	maxEarlyDataSizeQUIC = 0xffffffff
+	// defaultServerName is the server name used when the generated
+	// one is empty.
+	defaultServerName = "localhost"
+)
+
+var defaultALPN = []string{"h3", "h2", "http/1.1"}
+
+var namedGroups = []uint16{
+	GroupSecp256r1,
+	GroupSecp384r1,
+	GroupSecp521r1,
+	GroupX25519,
+	GroupX448,
+	GroupFFDHE2048,
+	GroupX25519MLKEM768,
+}
+
+var signatureSchemes = []uint16{
+	0x0403, // ecdsa_secp256r1_sha256
+	0x0503, // ecdsa_secp384r1_sha384
+	0x0603, // ecdsa_secp521r1_sha512
+	0x0804, // rsa_pss_rsae_sha256
+	0x0805, // rsa_pss_rsae_sha384
+	0x0806, // rsa_pss_rsae_sha512
+	0x0807, // ed25519
+	0x0808, // ed448
+	0x0809, // rsa_pss_pss_sha256
+	0x0401, // rsa_pkcs1_sha256
+	0x0501, // rsa_pkcs1_sha384
+	0x0601, // rsa_pkcs1_sha512
+}
+
+// pskKeyExchangeModes are psk_ke(0) and psk_dhe_ke(1).
+var pskKeyExchangeModes = []uint8{0, 1}
+
+var clientHelloExtensions = []uint16{
+	ExtensionServerName,
+	ExtensionSupportedGroups,
+	ExtensionSignatureAlgorithms,
+	ExtensionALPN,
+	ExtensionEarlyData,
+	ExtensionPSKKeyExchangeModes,
+	ExtensionKeyShare,
+	ExtensionGREASE,
+}
+
+var serverHelloExtensions = []uint16{
+	ExtensionPreSharedKey,
+	ExtensionKeyShare,
+}
+
+var encryptedExtensions = []uint16{
+	ExtensionServerName,
+	ExtensionSupportedGroups,
+	ExtensionALPN,
+	ExtensionEarlyData,
+}
+
+var certificateRequestExtensions = []uint16{
+	ExtensionGREASE,
+}
+
+var newSessionTicketExtensions = []uint16{
+	ExtensionEarlyData,
+	ExtensionGREASE,
+}
+
+// keyShareLen returns the length of key_exchange of group.  If server
+// is true, it returns the length of the server share.
+func keyShareLen(group uint16, server bool) int {
+	switch group {
+	case GroupSecp256r1:
+		return 65
+	case GroupSecp384r1:
+		return 97
+	case GroupSecp521r1:
+		return 133
+	case GroupX25519:
+		return 32
+	case GroupX448:
+		return 56
+	case GroupFFDHE2048:
+		return 256
+	case GroupX25519MLKEM768:
+		if server {
+			return 1120
+		}
+
+		return 1216
+	default:
+		return 0
+	}
+}
+
+// consumeList returns a non-empty subset of s in the order chosen by
+// consuming bytes from the input data.  If g.illegal is true, the
+// subset might be empty.
+func consumeList[T any](g *handshakeGen, s []T) []T {
+	res := fuzz.ConsumeSubset(g.fdp, s)
+	fuzz.Shuffle(g.fdp, res)
+
+	if len(res) == 0 && !g.illegal {
+		res = s[:1:1]
+	}
+
+	return res
+}
+
+// consumeClientHelloExtensions returns the extension types in
+// ClientHello.  supported_versions, and quic_transport_parameters in
+// QUIC are always included.
+func (g *handshakeGen) consumeClientHelloExtensions() []uint16 {
+	exts := fuzz.ConsumeSubset(g.fdp, clientHelloExtensions)
+	exts = append(exts, ExtensionSupportedVersions)
+
+	if g.opts.QUIC {
+		exts = append(exts, ExtensionQUICTransportParameters)
+	}
+
+	// key_share requires supported_groups.
+	if slices.Contains(exts, ExtensionKeyShare) &&
+		!slices.Contains(exts, ExtensionSupportedGroups) && !g.illegal {
+		exts = append(exts, ExtensionSupportedGroups)
+	}
+
+	return g.shuffleExtensions(exts)
+}
+
+// shuffleExtensions shuffles exts.  If g.illegal is true, some
+// extensions might be duplicated.
+func (g *handshakeGen) shuffleExtensions(exts []uint16) []uint16 {
+	if g.illegal {
+		exts = append(exts, fuzz.ConsumeSubset(g.fdp, exts)...)
+	}
+
+	fuzz.Shuffle(g.fdp, exts)
+
+	return exts
+}
+
+// appendExtensions appends the extensions of types exts in the message
+// of type typ to b.
+func (g *handshakeGen) appendExtensions(
+	b []byte, typ uint8, exts []uint16,
+) []byte {
+	var (
+		list []byte
+		// groups is supported_groups in ClientHello.  key_share
+		// must offer a subset of them in the same order.
+		groups []uint16
+	)
+
+	if typ == HandshakeTypeClientHello {
+		groups = consumeList(g, namedGroups)
+	}
+
+	for _, ext := range exts {
+		if ext == ExtensionGREASE {
+			ext = ConsumeGREASE(g.fdp)
+		}
+
+		list = binary.BigEndian.AppendUint16(list, ext)
+		list = g.appendVector(list, 2, g.extensionData(typ, ext, groups))
+	}
+
+	return g.appendVector(b, 2, list)
+}
+
+// extensionData returns extension_data of extension type ext in the
+// message of type typ.
+func (g *handshakeGen) extensionData(
+	typ uint8, ext uint16, groups []uint16,
+) []byte {
+	fdp := g.fdp
+	clientHello := typ == HandshakeTypeClientHello
+
+	switch ext {
+	case ExtensionServerName:
+		if !clientHello {
+			return nil
+		}
+
+		return g.appendVector(nil, 2, g.consumeServerName())
+	case ExtensionSupportedGroups:
+		if !clientHello {
+			groups = consumeList(g, namedGroups)
+		}
+
+		return g.appendVector(nil, 2, appendUint16s(nil, groups))
+	case ExtensionSignatureAlgorithms:
+		return g.appendVector(nil, 2,
+			appendUint16s(nil, consumeList(g, signatureSchemes)))
+	case ExtensionALPN:
+		return g.appendVector(nil, 2, g.consumeProtocols(clientHello))
+	case ExtensionEarlyData:
+		if typ != HandshakeTypeNewSessionTicket {
+			return nil
+		}
+
+		size := fdp.ConsumeUint32()
+		if g.opts.QUIC && !g.illegal {
+			size = maxEarlyDataSizeQUIC
+		}
+
+		return binary.BigEndian.AppendUint32(nil, size)
+	case ExtensionSupportedVersions:
+		if !clientHello {
+			return binary.BigEndian.AppendUint16(nil, VersionTLS13)
+		}
+
+		versions := fuzz.ConsumeSubset(fdp,
+			[]uint16{VersionTLS12, ConsumeGREASE(fdp)})
+		versions = append(versions, VersionTLS13)
+		fuzz.Shuffle(fdp, versions)
+
+		return g.appendVector(nil, 1, appendUint16s(nil, versions))
+	case ExtensionPSKKeyExchangeModes:
+		return g.appendVector(nil, 1, consumeList(g, pskKeyExchangeModes))
+	case ExtensionKeyShare:
+		if !clientHello {
+			group := namedGroups[fdp.ConsumeIntInRange(0, len(namedGroups)-1)]
+
+			return g.appendKeyShareEntry(nil, group, true)
+		}
+
+		var shares []byte
+
+		for _, group := range fuzz.ConsumeSubset(fdp, groups) {
+			shares = g.appendKeyShareEntry(shares, group, false)
+		}
+
+		return g.appendVector(nil, 2, shares)
+	case ExtensionPreSharedKey:
+		// selected_identity
+		return binary.BigEndian.AppendUint16(nil, fdp.ConsumeUint16())
+	case ExtensionQUICTransportParameters:
+		return quic.ConsumeTransportParameters(fdp,
+			&quic.TransportParameterOptions{
+				Server:       !clientHello,
+				AllowIllegal: g.illegal,
+			})
+	default:
+		// GREASE extension.
+		return g.consumeData(0)
+	}
+}
+
+// consumeServerName returns ServerNameList which contains a
+// host_name.
+func (g *handshakeGen) consumeServerName() []byte {
+	name := g.fdp.ConsumeDictionaryString(g.maxDataLen)
+	if name == "" && !g.illegal {
+		name = defaultServerName
+	}
+
+	// name_type is host_name(0).
+	return g.appendVector([]byte{0}, 2, []byte(name))
+}
+
+// consumeProtocols returns ProtocolNameList.  If clientHello is false,
+// the list contains exactly one protocol.
+func (g *handshakeGen) consumeProtocols(clientHello bool) []byte {
+	fdp := g.fdp
+	protos := fuzz.ConsumeSubset(fdp, g.alpn)
+
+	if fdp.ConsumeBool() {
+		p := fdp.ConsumeDictionaryString(maxProtocolLen)
+		if p != "" || g.illegal {
+			protos = append(protos, p)
+		}
+	}
+
+	fuzz.Shuffle(fdp, protos)
+
+	if !g.illegal {
+		if len(protos) == 0 {
+			protos = g.alpn[:1]
+		}
+
+		if !clientHello {
+			protos = protos[:1]
+		}
+	}
+
+	var b []byte
+
+	for _, p := range protos {
+		b = g.appendVector(b, 1, []byte(p))
+	}
+
+	return b
+}
+
+// appendKeyShareEntry appends KeyShareEntry of group to b.
+func (g *handshakeGen) appendKeyShareEntry(
+	b []byte, group uint16, server bool,
+) []byte {
+	n := keyShareLen(group, server)
+	if g.illegal && g.fdp.ConsumeBool() {
+		n = g.fdp.ConsumeIntInRange(0, g.maxDataLen)
+	}
+
+	key := gen.AppendZeroPadded(nil, g.fdp, n)
+
+	switch group {
+	case GroupSecp256r1, GroupSecp384r1, GroupSecp521r1:
+		// Uncompressed point.
+		if len(key) != 0 && !g.illegal {
+			key[0] = 4
+		}
+	}
+
+	b = binary.BigEndian.AppendUint16(b, group)
+
+	return g.appendVector(b, 2, key)
+}
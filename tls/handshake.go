@@ -0,0 +1,470 @@
+// Package tls provides helpers which generate TLS 1.3 handshake
+// messages and records from FuzzedDataProvider.
+package tls
+
+import (
+	"encoding/binary"
+
+	fuzz "github.com/ngtcp2/fuzzeddataprovider-go"
+	"github.com/ngtcp2/fuzzeddataprovider-go/internal/gen"
+)
+
+// Handshake types defined in RFC 8446.
+const (
+	HandshakeTypeClientHello         = 1
+	HandshakeTypeServerHello         = 2
+	HandshakeTypeNewSessionTicket    = 4
+	HandshakeTypeEndOfEarlyData      = 5
+	HandshakeTypeEncryptedExtensions = 8
+	HandshakeTypeCertificate         = 11
+	HandshakeTypeCertificateRequest  = 13
+	HandshakeTypeCertificateVerify   = 15
+	HandshakeTypeFinished            = 20
+	HandshakeTypeKeyUpdate           = 24
+)
+
+// Cipher suites defined in RFC 8446.
+const (
+	CipherSuiteAES128GCMSHA256        = 0x1301
+	CipherSuiteAES256GCMSHA384        = 0x1302
+	CipherSuiteChaCha20Poly1305SHA256 = 0x1303
+	CipherSuiteAES128CCMSHA256        = 0x1304
+	CipherSuiteAES128CCM8SHA256       = 0x1305
+)
+
+// VersionTLS13 is the version number of TLS 1.3, and VersionTLS12 is
+// the legacy version number in TLS 1.3 messages.
+const (
+	VersionTLS12 = 0x0303
+	VersionTLS13 = 0x0304
+)
+
+const (
+	// randomLen is the length of Random in ClientHello and
+	// ServerHello.
+	randomLen = 32
+	// maxSessionIDLen is the maximum length of legacy_session_id.
+	maxSessionIDLen = 32
+	// maxTicketLifetime is the maximum ticket_lifetime in seconds.
+	maxTicketLifetime = 604800
+	// maxCertificates is the maximum number of certificates in
+	// Certificate message.
+	maxCertificates = 4
+	// maxContextLen is the maximum length of
+	// certificate_request_context.
+	maxContextLen = 255
+)
+
+var cipherSuites = []uint16{
+	CipherSuiteAES128GCMSHA256,
+	CipherSuiteAES256GCMSHA384,
+	CipherSuiteChaCha20Poly1305SHA256,
+	CipherSuiteAES128CCMSHA256,
+	CipherSuiteAES128CCM8SHA256,
+}
+
+var handshakeTypes = []uint8{
+	HandshakeTypeClientHello,
+	HandshakeTypeServerHello,
+	HandshakeTypeNewSessionTicket,
+	HandshakeTypeEndOfEarlyData,
+	HandshakeTypeEncryptedExtensions,
+	HandshakeTypeCertificate,
+	HandshakeTypeCertificateRequest,
+	HandshakeTypeCertificateVerify,
+	HandshakeTypeFinished,
+	HandshakeTypeKeyUpdate,
+}
+
+// quicHandshakeTypes are the handshake types used in QUIC.  QUIC does
+// not use EndOfEarlyData and KeyUpdate messages.
+var quicHandshakeTypes = []uint8{
+	HandshakeTypeClientHello,
+	HandshakeTypeServerHello,
+	HandshakeTypeNewSessionTicket,
+	HandshakeTypeEncryptedExtensions,
+	HandshakeTypeCertificate,
+	HandshakeTypeCertificateRequest,
+	HandshakeTypeCertificateVerify,
+	HandshakeTypeFinished,
+}
+
+// Options controls the handshake messages and records generated by
+// this package.  The zero value generates any TLS 1.3 handshake
+// message with default limits.
+type Options struct {
+	// Types is the handshake types to generate.  If Types is empty,
+	// all handshake types are generated.
+	Types []uint8
+	// MaxMessages is the maximum number of messages
+	// ConsumeHandshakeMessages generates.  If it is 0, 16 is used.
+	MaxMessages int
+	// MaxDataLen is the maximum length of variable length data, such
+	// as certificates, signatures, tickets and server name.  If it is
+	// 0, 256 is used.
+	MaxDataLen int
+	// CipherSuites is the cipher suites offered in ClientHello and
+	// selected in ServerHello.  If it is empty, the cipher suites
+	// defined in RFC 8446 are used.
+	CipherSuites []uint16
+	// ALPN is the application protocols offered in ClientHello and
+	// selected in EncryptedExtensions.  If it is empty, "h3", "h2"
+	// and "http/1.1" are used.  An extra protocol might be generated
+	// by ConsumeDictionaryString.
+	ALPN []string
+	// QUIC generates the messages for QUIC as described in RFC 9001.
+	// ClientHello and EncryptedExtensions carry
+	// quic_transport_parameters extension, legacy_session_id is
+	// empty, and neither EndOfEarlyData nor KeyUpdate is generated.
+	QUIC bool
+	// AllowIllegal allows messages which violate RFC 8446, such as
+	// unknown handshake types, length fields which do not match data,
+	// duplicate extensions, empty lists which must not be empty, and
+	// key shares of invalid length.
+	AllowIllegal bool
+}
+
+type handshakeGen struct {
+	fdp          *fuzz.FuzzedDataProvider
+	opts         *Options
+	types        []uint8
+	cipherSuites []uint16
+	alpn         []string
+	maxDataLen   int
+	// illegal is true if the message being generated may violate RFC
+	// 8446.
+	illegal bool
+}
+
+func newHandshakeGen(
+	fdp *fuzz.FuzzedDataProvider, opts *Options,
+) *handshakeGen {
+	opts = gen.OrZero(opts)
+
+	g := &handshakeGen{
+		fdp:          fdp,
+		opts:         opts,
+		types:        handshakeTypes,
+		cipherSuites: cipherSuites,
+		alpn:         defaultALPN,
+		maxDataLen:   gen.Limit(opts.MaxDataLen, gen.DefaultMaxDataLen),
+	}
+
+	switch {
+	case len(opts.Types) != 0:
+		g.types = opts.Types
+	case opts.QUIC:
+		g.types = quicHandshakeTypes
+	}
+
+	if len(opts.CipherSuites) != 0 {
+		g.cipherSuites = opts.CipherSuites
+	}
+
+	if len(opts.ALPN) != 0 {
+		g.alpn = opts.ALPN
+	}
+
+	return g
+}
+
+// ConsumeGREASE returns a GREASE value of the form 0x?a?a defined in
+// RFC 8701 by consuming bytes from the input data.
+func ConsumeGREASE(fdp *fuzz.FuzzedDataProvider) uint16 {
+	return 0x0a0a + 0x1010*fdp.ConsumeUint16InRange(0, 15)
+}
+
+// ConsumeClientHello returns an encoded ClientHello message including
+// the handshake header generated by consuming bytes from the input
+// data.  The extensions, cipher suites, key shares and application
+// protocols, and their order are chosen by the provider.  opts may be
+// nil.
+func ConsumeClientHello(fdp *fuzz.FuzzedDataProvider, opts *Options) []byte {
+	g := newHandshakeGen(fdp, opts)
+	g.illegal = gen.ConsumeIllegal(fdp, g.opts.AllowIllegal)
+
+	return g.appendHandshake(nil, HandshakeTypeClientHello)
+}
+
+// ConsumeHandshakeMessage returns an encoded handshake message
+// including the handshake header generated by consuming bytes from the
+// input data.  opts may be nil.  If there is no input data left, it
+// returns a ClientHello message unless opts.Types says otherwise.
+func ConsumeHandshakeMessage(
+	fdp *fuzz.FuzzedDataProvider, opts *Options,
+) []byte {
+	return newHandshakeGen(fdp, opts).appendMessage(nil)
+}
+
+// ConsumeHandshakeMessages returns a sequence of encoded handshake
+// messages generated by consuming bytes from the input data.  The
+// number of messages is chosen in the same way as fuzz.ConsumeSlice.
+// The messages are not ordered as in a real handshake, so that the
+// state machine of the parser is exercised.  opts may be nil.
+func ConsumeHandshakeMessages(
+	fdp *fuzz.FuzzedDataProvider, opts *Options,
+) []byte {
+	g := newHandshakeGen(fdp, opts)
+
+	var b []byte
+
+	for range gen.Limit(g.opts.MaxMessages, gen.DefaultMaxCount) {
+		if fdp.ConsumeUint8() == 0 {
+			break
+		}
+
+		b = g.appendMessage(b)
+	}
+
+	return b
+}
+
+func (g *handshakeGen) appendMessage(b []byte) []byte {
+	fdp := g.fdp
+
+	g.illegal = gen.ConsumeIllegal(fdp, g.opts.AllowIllegal)
+
+	typ := g.types[fdp.ConsumeIntInRange(0, len(g.types)-1)]
+	if g.illegal && fdp.ConsumeBool() {
+		typ = fdp.ConsumeUint8()
+	}
+
+	return g.appendHandshake(b, typ)
+}
+
+// appendHandshake appends a handshake message of type typ including
+// the handshake header to b.
+func (g *handshakeGen) appendHandshake(b []byte, typ uint8) []byte {
+	var body []byte
+
+	switch typ {
+	case HandshakeTypeClientHello:
+		body = g.clientHello()
+	case HandshakeTypeServerHello:
+		body = g.serverHello()
+	case HandshakeTypeNewSessionTicket:
+		body = g.newSessionTicket()
+	case HandshakeTypeEndOfEarlyData:
+		// No fields.
+	case HandshakeTypeEncryptedExtensions:
+		body = g.encryptedExtensions()
+	case HandshakeTypeCertificate:
+		body = g.certificate()
+	case HandshakeTypeCertificateRequest:
+		body = g.certificateRequest()
+	case HandshakeTypeCertificateVerify:
+		body = g.certificateVerify()
+	case HandshakeTypeFinished:
+		// verify_data of SHA-256 or SHA-384.
+		n := 32
+		if g.fdp.ConsumeBool() {
+			n = 48
+		}
+
+		body = gen.AppendZeroPadded(nil, g.fdp, n)
+	case HandshakeTypeKeyUpdate:
+		// request_update is update_not_requested(0) or
+		// update_requested(1).
+		body = []byte{g.fdp.ConsumeUint8InRange(0, 1)}
+		if g.illegal {
+			body[0] = g.fdp.ConsumeUint8()
+		}
+	default:
+		// Unknown handshake type.
+		body = g.consumeData(0)
+	}
+
+	b = append(b, typ)
+
+	return g.appendVector(b, 3, body)
+}
+
+func (g *handshakeGen) clientHello() []byte {
+	fdp := g.fdp
+
+	b := g.consumeLegacyVersion()
+	b = gen.AppendZeroPadded(b, g.fdp, randomLen)
+	b = g.appendSessionID(b)
+
+	suites := fuzz.ConsumeSubset(fdp, g.cipherSuites)
+	if fdp.ConsumeBool() {
+		suites = append(suites, ConsumeGREASE(fdp))
+	}
+
+	fuzz.Shuffle(fdp, suites)
+
+	if len(suites) == 0 && !g.illegal {
+		suites = g.cipherSuites[:1]
+	}
+
+	b = g.appendVector(b, 2, appendUint16s(nil, suites))
+
+	// legacy_compression_methods
+	compression := []byte{0}
+	if g.illegal && fdp.ConsumeBool() {
+		compression = g.consumeData(0)
+	}
+
+	b = g.appendVector(b, 1, compression)
+
+	return g.appendExtensions(b, HandshakeTypeClientHello,
+		g.consumeClientHelloExtensions())
+}
+
+func (g *handshakeGen) serverHello() []byte {
+	fdp := g.fdp
+
+	b := g.consumeLegacyVersion()
+	b = gen.AppendZeroPadded(b, g.fdp, randomLen)
+	b = g.appendSessionID(b)
+
+	suite := g.cipherSuites[fdp.ConsumeIntInRange(0, len(g.cipherSuites)-1)]
+	if g.illegal && fdp.ConsumeBool() {
+		suite = fdp.ConsumeUint16()
+	}
+
+	b = binary.BigEndian.AppendUint16(b, suite)
+
+	// legacy_compression_method
+	b = append(b, 0)
+
+	exts := fuzz.ConsumeSubset(fdp, serverHelloExtensions)
+	exts = append(exts, ExtensionSupportedVersions)
+
+	return g.appendExtensions(b, HandshakeTypeServerHello,
+		g.shuffleExtensions(exts))
+}
+
+func (g *handshakeGen) newSessionTicket() []byte {
+	fdp := g.fdp
+
+	lifetime := fdp.ConsumeUint32InRange(0, maxTicketLifetime)
+	if g.illegal {
+		lifetime = fdp.ConsumeUint32()
+	}
+
+	b := binary.BigEndian.AppendUint32(nil, lifetime)
+	// ticket_age_add
+	b = gen.AppendZeroPadded(b, g.fdp, 4)
+	// ticket_nonce
+	b = g.appendVector(b, 1, g.consumeDataMax(0, 255))
+	// ticket
+	b = g.appendVector(b, 2, g.consumeData(1))
+
+	exts := fuzz.ConsumeSubset(fdp, newSessionTicketExtensions)
+
+	return g.appendExtensions(b, HandshakeTypeNewSessionTicket,
+		g.shuffleExtensions(exts))
+}
+
+func (g *handshakeGen) encryptedExtensions() []byte {
+	exts := fuzz.ConsumeSubset(g.fdp, encryptedExtensions)
+	if g.opts.QUIC {
+		exts = append(exts, ExtensionQUICTransportParameters)
+	}
+
+	return g.appendExtensions(nil, HandshakeTypeEncryptedExtensions,
+		g.shuffleExtensions(exts))
+}
+
+func (g *handshakeGen) certificate() []byte {
+	fdp := g.fdp
+
+	// certificate_request_context
+	b := g.appendVector(nil, 1, g.consumeDataMax(0, maxContextLen))
+
+	var list []byte
+
+	for range maxCertificates {
+		if fdp.ConsumeUint8() == 0 {
+			break
+		}
+
+		// cert_data
+		list = g.appendVector(list, 3, g.consumeData(1))
+		// extensions
+		list = g.appendVector(list, 2, nil)
+	}
+
+	return g.appendVector(b, 3, list)
+}
+
+func (g *handshakeGen) certificateRequest() []byte {
+	// certificate_request_context
+	b := g.appendVector(nil, 1, g.consumeDataMax(0, maxContextLen))
+
+	exts := fuzz.ConsumeSubset(g.fdp, certificateRequestExtensions)
+	exts = append(exts, ExtensionSignatureAlgorithms)
+
+	return g.appendExtensions(b, HandshakeTypeCertificateRequest,
+		g.shuffleExtensions(exts))
+}
+
+func (g *handshakeGen) certificateVerify() []byte {
+	fdp := g.fdp
+
+	alg := signatureSchemes[fdp.ConsumeIntInRange(0, len(signatureSchemes)-1)]
+	if g.illegal && fdp.ConsumeBool() {
+		alg = fdp.ConsumeUint16()
+	}
+
+	b := binary.BigEndian.AppendUint16(nil, alg)
+
+	return g.appendVector(b, 2, g.consumeData(0))
+}
+
+// consumeLegacyVersion returns legacy_version, which is always TLS 1.2
+// unless g.illegal is true.
+func (g *handshakeGen) consumeLegacyVersion() []byte {
+	v := uint16(VersionTLS12)
+	if g.illegal && g.fdp.ConsumeBool() {
+		v = g.fdp.ConsumeUint16()
+	}
+
+	return binary.BigEndian.AppendUint16(nil, v)
+}
+
+// appendSessionID appends legacy_session_id, which is empty in QUIC
+// unless g.illegal is true, to b.
+func (g *handshakeGen) appendSessionID(b []byte) []byte {
+	if g.opts.QUIC && !g.illegal {
+		return append(b, 0)
+	}
+
+	return g.appendVector(b, 1, g.consumeDataMax(0, maxSessionIDLen))
+}
+
+// consumeData returns data of length from minLen to g.maxDataLen.
+func (g *handshakeGen) consumeData(minLen int) []byte {
+	return g.consumeDataMax(minLen, max(minLen, g.maxDataLen))
+}
+
+// consumeDataMax returns data of length from minLen to maxLen.  If
+// fewer bytes than the chosen length remain, the rest is filled with
+// zeros.
+func (g *handshakeGen) consumeDataMax(minLen, maxLen int) []byte {
+	fdp := g.fdp
+
+	return gen.AppendZeroPadded(nil, fdp, fdp.ConsumeIntInRange(minLen, maxLen))
+}
+
+// appendVector appends the length of data in n bytes and data to b.
+// If g.illegal is true, the length might not match data.
+func (g *handshakeGen) appendVector(b []byte, n int, data []byte) []byte {
+	return gen.AppendLengthPrefixed(g.fdp, g.illegal, b, data, 1<<(8*n)-1,
+		func(b []byte, l uint64) []byte {
+			for i := n - 1; i >= 0; i-- {
+				b = append(b, byte(l>>(8*i)))
+			}
+
+			return b
+		})
+}
+
+func appendUint16s(b []byte, s []uint16) []byte {
+	for _, v := range s {
+		b = binary.BigEndian.AppendUint16(b, v)
+	}
+
+	return b
+}
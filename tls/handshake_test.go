@@ -0,0 +1,261 @@
+package tls
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	fuzz "github.com/ngtcp2/fuzzeddataprovider-go"
+	"github.com/ngtcp2/fuzzeddataprovider-go/internal/gentest"
+)
+
+type reader struct {
+	gentest.Reader
+}
+
+func newReader(b []byte) *reader {
+	return &reader{gentest.Reader{B: b}}
+}
+
+// vector returns a reader of the vector whose length has n bytes.
+func (r *reader) vector(n int) *reader {
+	return newReader(r.Bytes(r.Uint(n)))
+}
+
+func (r *reader) uint16s() []uint16 {
+	r.Check(len(r.B)%2 == 0)
+
+	var s []uint16
+
+	for len(r.B) != 0 {
+		s = append(s, uint16(r.Uint(2)))
+	}
+
+	return s
+}
+
+func (r *reader) done() {
+	r.Check(len(r.B) == 0)
+}
+
+// parseMessages validates the handshake messages in b according to
+// RFC 8446.
+func parseMessages(b []byte, quic bool) error {
+	return gentest.Parse(func() { newReader(b).messages(quic) })
+}
+
+func (r *reader) messages(quic bool) {
+	for len(r.B) != 0 {
+		typ := uint8(r.Uint(1))
+		body := r.vector(3)
+
+		switch typ {
+		case HandshakeTypeClientHello:
+			body.Check(body.Uint(2) == VersionTLS12)
+			body.Bytes(randomLen)
+
+			sid := body.vector(1)
+			body.Check(len(sid.B) <= maxSessionIDLen && (!quic || len(sid.B) == 0))
+			body.Check(len(body.vector(2).uint16s()) != 0)
+			body.Check(slices.Equal(body.vector(1).B, []byte{0}))
+			body.extensions(typ, quic)
+		case HandshakeTypeServerHello:
+			body.Check(body.Uint(2) == VersionTLS12)
+			body.Bytes(randomLen)
+			body.Check(len(body.vector(1).B) <= maxSessionIDLen)
+			body.Uint(2)
+			body.Check(body.Uint(1) == 0)
+			body.extensions(typ, quic)
+		case HandshakeTypeNewSessionTicket:
+			body.Check(body.Uint(4) <= maxTicketLifetime)
+			body.Uint(4)
+			body.vector(1)
+			body.Check(len(body.vector(2).B) != 0)
+			body.extensions(typ, quic)
+		case HandshakeTypeEndOfEarlyData:
+		case HandshakeTypeEncryptedExtensions:
+			body.extensions(typ, quic)
+		case HandshakeTypeCertificate:
+			body.vector(1)
+
+			list := body.vector(3)
+			for len(list.B) != 0 {
+				list.Check(len(list.vector(3).B) != 0)
+				list.vector(2)
+			}
+		case HandshakeTypeCertificateRequest:
+			body.vector(1)
+			body.extensions(typ, quic)
+		case HandshakeTypeCertificateVerify:
+			body.Check(slices.Contains(signatureSchemes, uint16(body.Uint(2))))
+			body.vector(2)
+		case HandshakeTypeFinished:
+			body.Check(len(body.B) == 32 || len(body.B) == 48)
+			body.B = nil
+		case HandshakeTypeKeyUpdate:
+			body.Check(body.Uint(1) <= 1)
+		default:
+			panic(gentest.ErrMalformed)
+		}
+
+		body.done()
+	}
+}
+
+func (r *reader) extensions(typ uint8, quic bool) {
+	list := r.vector(2)
+	seen := make(map[uint16][]byte)
+
+	var order []uint16
+
+	for len(list.B) != 0 {
+		ext := uint16(list.Uint(2))
+
+		_, dup := seen[ext]
+		r.Check(!dup)
+
+		seen[ext] = list.vector(2).B
+		order = append(order, ext)
+	}
+
+	for _, ext := range order {
+		data := newReader(seen[ext])
+
+		switch ext {
+		case ExtensionServerName:
+			if typ == HandshakeTypeClientHello {
+				names := data.vector(2)
+				r.Check(names.Uint(1) == 0)
+				r.Check(len(names.vector(2).B) != 0)
+				names.done()
+			}
+		case ExtensionSupportedGroups:
+			r.Check(len(data.vector(2).uint16s()) != 0)
+		case ExtensionSignatureAlgorithms:
+			r.Check(len(data.vector(2).uint16s()) != 0)
+		case ExtensionALPN:
+			protos := data.vector(2)
+			n := 0
+
+			for ; len(protos.B) != 0; n++ {
+				r.Check(len(protos.vector(1).B) != 0)
+			}
+
+			r.Check(n != 0 && (typ == HandshakeTypeClientHello || n == 1))
+		case ExtensionEarlyData:
+			if typ == HandshakeTypeNewSessionTicket {
+				size := data.Uint(4)
+				r.Check(!quic || size == maxEarlyDataSizeQUIC)
+			}
+		case ExtensionSupportedVersions:
+			if typ == HandshakeTypeClientHello {
+				r.Check(slices.Contains(data.vector(1).uint16s(), VersionTLS13))
+			} else {
+				r.Check(data.Uint(2) == VersionTLS13)
+			}
+		case ExtensionPSKKeyExchangeModes:
+			modes := data.vector(1)
+			r.Check(len(modes.B) != 0)
+
+			for _, m := range modes.B {
+				r.Check(m <= 1)
+			}
+		case ExtensionKeyShare:
+			if typ != HandshakeTypeClientHello {
+				r.keyShareEntry(data, true)
+
+				break
+			}
+
+			groups := newReader(seen[ExtensionSupportedGroups]).vector(2).
+				uint16s()
+			shares := data.vector(2)
+
+			for len(shares.B) != 0 {
+				group := r.keyShareEntry(shares, false)
+				i := slices.Index(groups, group)
+				r.Check(i != -1)
+				groups = groups[i+1:]
+			}
+		case ExtensionPreSharedKey:
+			data.Uint(2)
+		case ExtensionQUICTransportParameters:
+			r.Check(quic)
+
+			data.B = nil
+		default:
+			r.Check(ext&0x0f0f == 0x0a0a && ext>>12 == ext>>4&0x0f)
+
+			data.B = nil
+		}
+
+		data.done()
+	}
+
+	if typ == HandshakeTypeClientHello {
+		r.Check(slices.Contains(order, ExtensionSupportedVersions))
+	}
+
+	if quic && (typ == HandshakeTypeClientHello ||
+		typ == HandshakeTypeEncryptedExtensions) {
+		r.Check(slices.Contains(order, ExtensionQUICTransportParameters))
+	}
+
+	if typ == HandshakeTypeCertificateRequest {
+		r.Check(slices.Contains(order, ExtensionSignatureAlgorithms))
+	}
+}
+
+func (r *reader) keyShareEntry(data *reader, server bool) uint16 {
+	group := uint16(data.Uint(2))
+	key := data.vector(2)
+
+	r.Check(len(key.B) == keyShareLen(group, server))
+
+	return group
+}
+
+func TestConsumeGREASE(t *testing.T) {
+	fdp := fuzz.NewFuzzedDataProvider([]byte{0x0f})
+
+	assert.Equal(t, uint16(0xfafa), ConsumeGREASE(fdp))
+	assert.Equal(t, uint16(0x0a0a), ConsumeGREASE(fdp))
+}
+
+func TestConsumeHandshakeMessage(t *testing.T) {
+	fdp := fuzz.NewFuzzedDataProvider(nil)
+
+	assert.Equal(t, []byte{HandshakeTypeEndOfEarlyData, 0x00, 0x00, 0x00},
+		ConsumeHandshakeMessage(fdp, &Options{
+			Types: []uint8{HandshakeTypeEndOfEarlyData},
+		}))
+
+	fdp = fuzz.NewFuzzedDataProvider([]byte{0x01})
+
+	assert.Equal(t, []byte{HandshakeTypeKeyUpdate, 0x00, 0x00, 0x01, 0x01},
+		ConsumeHandshakeMessage(fdp, &Options{
+			Types: []uint8{HandshakeTypeKeyUpdate},
+		}))
+}
+
+func TestConsumeHandshakeMessagesProperty(t *testing.T) {
+	for _, quic := range []bool{false, true} {
+		gentest.Check(t, func(data []byte, illegal bool) []byte {
+			return ConsumeHandshakeMessages(fuzz.NewFuzzedDataProvider(data),
+				&Options{
+					QUIC:         quic,
+					AllowIllegal: illegal,
+				})
+		}, func(b []byte) error { return parseMessages(b, quic) })
+	}
+}
+
+func TestConsumeClientHello(t *testing.T) {
+	fdp := fuzz.NewFuzzedDataProvider(nil)
+	b := ConsumeClientHello(fdp, nil)
+
+	assert.Equal(t, uint8(HandshakeTypeClientHello), b[0])
+	require.NoError(t, parseMessages(b, false), "%x", b)
+}
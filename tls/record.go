@@ -0,0 +1,87 @@
+package tls
+
+import (
+	"encoding/binary"
+
+	fuzz "github.com/ngtcp2/fuzzeddataprovider-go"
+	"github.com/ngtcp2/fuzzeddataprovider-go/internal/gen"
+)
+
+// Content types defined in RFC 8446.
+const (
+	ContentTypeChangeCipherSpec = 20
+	ContentTypeAlert            = 21
+	ContentTypeHandshake        = 22
+	ContentTypeApplicationData  = 23
+)
+
+const (
+	// VersionTLS10 is the legacy_record_version which the record
+	// carrying initial ClientHello may use.
+	VersionTLS10 = 0x0301
+	// MaxFragmentLen is the maximum length of TLSPlaintext fragment.
+	MaxFragmentLen = 1 << 14
+	// maxIllegalFragmentLen is the maximum length of fragment when
+	// illegal records are allowed.  It exceeds the limit of
+	// TLSCiphertext.
+	maxIllegalFragmentLen = MaxFragmentLen + 256 + 1
+)
+
+// ConsumeRecords returns TLSPlaintext records of content type typ
+// which carry payload.  payload is split at the boundaries chosen by
+// consuming bytes from the input data.  If payload starts with
+// ClientHello, the first record might use VersionTLS10 as
+// legacy_record_version.  If payload is empty, it returns a single
+// record with empty fragment.  Only opts.AllowIllegal is used, and
+// opts may be nil.
+func ConsumeRecords(
+	fdp *fuzz.FuzzedDataProvider, typ uint8, payload []byte, opts *Options,
+) []byte {
+	opts = gen.OrZero(opts)
+	clientHello := typ == ContentTypeHandshake && len(payload) != 0 &&
+		payload[0] == HandshakeTypeClientHello
+
+	var b []byte
+
+	for first := true; first || len(payload) != 0; first = false {
+		illegal := gen.ConsumeIllegal(fdp, opts.AllowIllegal)
+
+		version := uint16(VersionTLS12)
+
+		switch {
+		case illegal && fdp.ConsumeBool():
+			version = fdp.ConsumeUint16()
+		case first && clientHello && fdp.ConsumeBool():
+			version = VersionTLS10
+		}
+
+		maxLen := MaxFragmentLen
+		if illegal {
+			maxLen = maxIllegalFragmentLen
+		}
+
+		// Zero-length fragments of Handshake and Alert are illegal.
+		minLen := 1
+		if illegal {
+			minLen = 0
+		}
+
+		n := 0
+		if len(payload) != 0 {
+			n = fdp.ConsumeIntInRange(minLen, min(maxLen, len(payload)))
+		}
+
+		length := uint16(n)
+		if illegal && fdp.ConsumeBool() {
+			length = fdp.ConsumeUint16()
+		}
+
+		b = append(b, typ)
+		b = binary.BigEndian.AppendUint16(b, version)
+		b = binary.BigEndian.AppendUint16(b, length)
+		b = append(b, payload[:n]...)
+		payload = payload[n:]
+	}
+
+	return b
+}
@@ -0,0 +1,29 @@
+package tls
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	fuzz "github.com/ngtcp2/fuzzeddataprovider-go"
+)
+
+func TestConsumeRecords(t *testing.T) {
+	fdp := fuzz.NewFuzzedDataProvider(nil)
+
+	assert.Equal(t, []byte{ContentTypeApplicationData, 0x03, 0x03, 0x00, 0x00},
+		ConsumeRecords(fdp, ContentTypeApplicationData, nil, nil))
+	assert.Equal(t, []byte{
+		ContentTypeHandshake, 0x03, 0x03, 0x00, 0x01, HandshakeTypeFinished,
+		ContentTypeHandshake, 0x03, 0x03, 0x00, 0x01, 0x00,
+	}, ConsumeRecords(fdp, ContentTypeHandshake,
+		[]byte{HandshakeTypeFinished, 0x00}, nil))
+
+	fdp = fuzz.NewFuzzedDataProvider([]byte{0x01, 0x01})
+
+	assert.Equal(t, []byte{
+		ContentTypeHandshake, 0x03, 0x01, 0x00, 0x02,
+		HandshakeTypeClientHello, 0x00,
+	}, ConsumeRecords(fdp, ContentTypeHandshake,
+		[]byte{HandshakeTypeClientHello, 0x00}, nil))
+}
@@ -0,0 +1,91 @@
+package fuzz
+
+import "encoding/binary"
+
+// consumeUvarint decodes LEB128-encoded unsigned integer from the front
+// of the input data.  If raw is true, it also returns the consumed
+// bytes, fixed up so that they form a valid encoding of the returned
+// value.
+func (fdp *FuzzedDataProvider) consumeUvarint(raw bool) (uint64, []byte) {
+	var (
+		v   uint64
+		enc []byte
+	)
+
+	for i := range binary.MaxVarintLen64 {
+		if len(fdp.data) == 0 {
+			break
+		}
+
+		c := fdp.data[0]
+		fdp.advance(1)
+
+		last := c < 0x80 || i == binary.MaxVarintLen64-1
+		if i == binary.MaxVarintLen64-1 {
+			// Only the least significant bit of the 10th byte fits
+			// in uint64.
+			c &= 0x01
+		}
+
+		v |= uint64(c&0x7f) << (7 * i)
+
+		if raw {
+			enc = append(enc, c)
+		}
+
+		if last {
+			break
+		}
+	}
+
+	if len(enc) != 0 {
+		// The input data might end in the middle of the encoding.
+		enc[len(enc)-1] &= 0x7f
+	}
+
+	return v, enc
+}
+
+// ConsumeUvarint returns uint64 decoded from the front of the input
+// data in the variable-length encoding used by encoding/binary and
+// Protocol Buffers.  It consumes at most binary.MaxVarintLen64 bytes,
+// and the excess bits of the last byte are ignored.  Overlong
+// encodings, such as 0x80 0x00, are accepted, so that the fuzzer
+// controls the encoding length as well as the value.  If the input
+// data ends in the middle of the encoding, the bytes read so far are
+// decoded.
+func (fdp *FuzzedDataProvider) ConsumeUvarint() uint64 {
+	v, _ := fdp.consumeUvarint(false)
+
+	return v
+}
+
+// ConsumeVarint returns int64 decoded in the same way as
+// ConsumeUvarint, and then zigzag-decoded as binary.Varint does.
+func (fdp *FuzzedDataProvider) ConsumeVarint() int64 {
+	return zigzag(fdp.ConsumeUvarint())
+}
+
+// ConsumeUvarintBytes is like ConsumeUvarint, but it also returns the
+// encoding of the value, which preserves the length of the consumed
+// bytes including overlong forms.  The encoding is fixed up so that it
+// is always decodable by binary.Uvarint: the continuation bit of the
+// last byte is cleared, and the excess bits of the 10th byte are
+// dropped.  If there is no input data left, it returns 0 and an empty
+// encoding.
+func (fdp *FuzzedDataProvider) ConsumeUvarintBytes() (uint64, []byte) {
+	return fdp.consumeUvarint(true)
+}
+
+// ConsumeVarintBytes is like ConsumeVarint, but it also returns the
+// encoding of the value in the same way as ConsumeUvarintBytes.
+func (fdp *FuzzedDataProvider) ConsumeVarintBytes() (int64, []byte) {
+	v, enc := fdp.consumeUvarint(true)
+
+	return zigzag(v), enc
+}
+
+// zigzag returns v zigzag-decoded.
+func zigzag(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
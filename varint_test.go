@@ -0,0 +1,78 @@
+package fuzz
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsumeUvarint(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{
+		0x01,
+		0xac, 0x02,
+		0x80, 0x00,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f,
+		0x81,
+	})
+
+	assert.Equal(t, uint64(1), fdp.ConsumeUvarint())
+	assert.Equal(t, uint64(300), fdp.ConsumeUvarint())
+	assert.Equal(t, uint64(0), fdp.ConsumeUvarint())
+	assert.Equal(t, uint64(math.MaxUint64), fdp.ConsumeUvarint())
+	assert.Equal(t, uint64(1), fdp.ConsumeUvarint())
+	assert.Equal(t, uint64(0), fdp.ConsumeUvarint())
+}
+
+func TestConsumeVarint(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{0x00, 0x01, 0x02, 0x03, 0xd7, 0x04})
+
+	assert.Equal(t, int64(0), fdp.ConsumeVarint())
+	assert.Equal(t, int64(-1), fdp.ConsumeVarint())
+	assert.Equal(t, int64(1), fdp.ConsumeVarint())
+	assert.Equal(t, int64(-2), fdp.ConsumeVarint())
+	assert.Equal(t, int64(-300), fdp.ConsumeVarint())
+}
+
+func TestConsumeUvarintBytes(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{
+		0x80, 0x80, 0x00,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xac, 0x82,
+	})
+
+	v, enc := fdp.ConsumeUvarintBytes()
+	assert.Equal(t, uint64(0), v)
+	assert.Equal(t, []byte{0x80, 0x80, 0x00}, enc)
+
+	v, enc = fdp.ConsumeUvarintBytes()
+	assert.Equal(t, uint64(math.MaxUint64), v)
+	assert.Equal(t, []byte{
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01,
+	}, enc)
+
+	dec, n := binary.Uvarint(enc)
+	assert.Equal(t, v, dec)
+	assert.Equal(t, len(enc), n)
+
+	v, enc = fdp.ConsumeUvarintBytes()
+	assert.Equal(t, uint64(300), v)
+	assert.Equal(t, []byte{0xac, 0x02}, enc)
+
+	v, enc = fdp.ConsumeUvarintBytes()
+	assert.Equal(t, uint64(0), v)
+	assert.Empty(t, enc)
+}
+
+func TestConsumeVarintBytes(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{0x81, 0x80, 0x00})
+
+	v, enc := fdp.ConsumeVarintBytes()
+	assert.Equal(t, int64(-1), v)
+	assert.Equal(t, []byte{0x81, 0x80, 0x00}, enc)
+
+	dec, n := binary.Varint(enc)
+	assert.Equal(t, v, dec)
+	assert.Equal(t, len(enc), n)
+}
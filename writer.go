@@ -0,0 +1,73 @@
+package fuzz
+
+import (
+	"bytes"
+	"io"
+)
+
+const (
+	writeAll = iota
+	writeShort
+	writeError
+)
+
+// writeWeights are the weights of writeAll, writeShort and writeError.
+var writeWeights = []uint{14, 1, 1}
+
+// Writer is an io.Writer which accepts the number of bytes chosen by
+// consuming bytes from the input data per Write, and fails at the
+// points chosen in the same way.  It captures the bytes it accepted.
+type Writer struct {
+	fdp  *FuzzedDataProvider
+	errs []error
+	err  error
+	buf  bytes.Buffer
+}
+
+// NewWriter returns new Writer.  errs are the errors which Writer may
+// inject.  If errs is empty, io.ErrClosedPipe is injected instead.
+func (fdp *FuzzedDataProvider) NewWriter(errs ...error) *Writer {
+	return &Writer{
+		fdp:  fdp,
+		errs: errs,
+	}
+}
+
+// Write implements io.Writer.  A partial write returns
+// io.ErrShortWrite, and the next call may succeed.  An injected error
+// is permanent; once Write returns it, it keeps returning the same
+// error.  If there is no input data left, it accepts all of p.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	switch w.fdp.ConsumeWeightedIndex(writeWeights) {
+	case writeShort:
+		n, _ := w.buf.Write(p[:w.fdp.ConsumeIntInRange(0, len(p)-1)])
+
+		return n, io.ErrShortWrite
+	case writeError:
+		if len(w.errs) == 0 {
+			w.err = io.ErrClosedPipe
+		} else {
+			w.err = w.errs[w.fdp.ConsumeIntInRange(0, len(w.errs)-1)]
+		}
+
+		n, _ := w.buf.Write(p[:w.fdp.ConsumeIntInRange(0, len(p)-1)])
+
+		return n, w.err
+	}
+
+	return w.buf.Write(p)
+}
+
+// Bytes returns the bytes accepted so far.  The returned slice is
+// valid until the next call of Write.
+func (w *Writer) Bytes() []byte {
+	return w.buf.Bytes()
+}
@@ -0,0 +1,66 @@
+package fuzz
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{0x01, 0x0e, 0x00})
+	w := fdp.NewWriter()
+
+	n, err := w.Write([]byte("foo"))
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	n, err = w.Write([]byte("bar"))
+
+	require.ErrorIs(t, err, io.ErrShortWrite)
+	assert.Equal(t, 1, n)
+
+	n, err = w.Write([]byte("ar"))
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	n, err = w.Write(nil)
+
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Equal(t, "foobar", string(w.Bytes()))
+}
+
+func TestWriterError(t *testing.T) {
+	fdp := NewFuzzedDataProvider([]byte{0x02, 0x0f})
+	w := fdp.NewWriter()
+
+	n, err := w.Write([]byte("foo bar"))
+
+	require.ErrorIs(t, err, io.ErrClosedPipe)
+	assert.Equal(t, 2, n)
+
+	n, err = w.Write([]byte("o bar"))
+
+	require.ErrorIs(t, err, io.ErrClosedPipe)
+	assert.Zero(t, n)
+	assert.Equal(t, "fo", string(w.Bytes()))
+
+	errDiskFull := errors.New("disk full")
+
+	fdp = NewFuzzedDataProvider([]byte{0x00, 0x0f, 0x00})
+	w = fdp.NewWriter(errDiskFull)
+
+	bw := bufio.NewWriterSize(w, 4)
+
+	_, err = bw.WriteString("foo bar")
+
+	require.NoError(t, err)
+	require.ErrorIs(t, bw.Flush(), errDiskFull)
+	assert.Equal(t, "foo ", string(w.Bytes()))
+}